@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/spf13/cobra"
+)
+
+var nameFlag string
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze [queries...] [-- codeql args]",
+	Short: "Runs CodeQL queries against CodeQL databases stored in the QLDB structure",
+	Long: `Runs CodeQL queries, suites or packs against the CodeQL databases stored in the QLDB structure, unpacking them if needed.
+
+The databases are selected like with the list command. The SARIF results are stored next to each database as <language>-<sha>.results/<name>.sarif and recorded in the database metadata. Pass additional 'codeql database analyze' arguments after a '--' separator.
+
+eg: gh-qldb analyze --nwo foo/bar --language java codeql/java-queries -- --threads 0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		queries, codeqlArgs := args, []string(nil)
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			queries, codeqlArgs = args[:dash], args[dash:]
+		}
+		analyze(queries, codeqlArgs)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+	analyzeCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to analyze the databases for.")
+	analyzeCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language of the databases to analyze.")
+	analyzeCmd.Flags().StringVarP(&shaFlag, "sha", "s", "", "The commit SHA, or SHA prefix, of the databases to analyze.")
+	analyzeCmd.Flags().StringVar(&nameFlag, "name", "", "The name of the analysis used in the SARIF file name. Derived from the queries by default.")
+	analyzeCmd.MarkFlagRequired("nwo")
+}
+
+func analyze(queries []string, codeqlArgs []string) {
+	store := newStore()
+	dbs, err := store.List(qldb.ListOptions{
+		NWO:      nwoFlag,
+		Language: languageFlag,
+		Sha:      shaFlag,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(dbs) == 0 {
+		log.Fatal(qldb.ErrNotFound)
+	}
+	opts := qldb.AnalyzeOptions{
+		Queries: queries,
+		Name:    nameFlag,
+		Args:    codeqlArgs,
+	}
+	failed := 0
+	for _, db := range dbs {
+		result, err := store.Analyze(db, opts)
+		if err != nil {
+			failed++
+			fmt.Printf("Failed to analyze '%s': %v\n", db.Path, err)
+			continue
+		}
+		fmt.Printf("Results written to '%s'\n", qldb.SarifPath(db.Path, result.Name))
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
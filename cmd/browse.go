@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "Browses the CodeQL databases stored in the QLDB structure",
+	Long: `Browses the CodeQL databases stored in the QLDB structure in an interactive terminal UI.
+
+Keys:
+  up/down, j/k   move          /       filter (fuzzy)
+  d              delete        v       verify
+  u              unpack        c       copy path to the clipboard
+  s              open a shell in the unpacked database
+  q, ctrl+c      quit`,
+	Run: func(cmd *cobra.Command, args []string) {
+		browse()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
+
+func browse() {
+	store := newStore()
+	// the UI owns the terminal
+	store.Log = nil
+	store.Progress = nil
+	dbs, err := store.List(qldb.ListOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var infos []*qldb.Database
+	for _, db := range dbs {
+		info, err := store.Info(db.Path, qldb.InfoOptions{})
+		if err != nil {
+			info = db
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].NWO != infos[j].NWO {
+			return infos[i].NWO < infos[j].NWO
+		}
+		return infos[i].Path < infos[j].Path
+	})
+
+	m := &browseModel{store: store, dbs: infos}
+	m.refresh()
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// browseRow is a line of the tree: an owner, a repository or a database.
+type browseRow struct {
+	depth int
+	label string
+	db    *qldb.Database
+}
+
+type browseModel struct {
+	store *qldb.Store
+	dbs   []*qldb.Database
+	rows  []browseRow
+	// cursor is the index of the selected row, always a database row.
+	cursor int
+	offset int
+	height int
+
+	filter    string
+	filtering bool
+	deleting  bool
+	status    string
+}
+
+// statusMsg reports the outcome of a background operation.
+type statusMsg string
+
+// shellMsg requests a shell in the unpacked database at path.
+type shellMsg string
+
+func (m *browseModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case statusMsg:
+		m.status = string(msg)
+	case shellMsg:
+		return m, m.execShell(string(msg))
+	case tea.KeyMsg:
+		if m.filtering {
+			return m, m.updateFilter(msg)
+		}
+		if m.deleting {
+			m.deleting = false
+			if msg.String() == "y" {
+				return m, m.delete()
+			}
+			m.status = "Delete cancelled"
+			return m, nil
+		}
+		return m, m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *browseModel) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filtering = false
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+	case tea.KeyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(m.filter); size > 0 {
+			m.filter = m.filter[:len(m.filter)-size]
+		}
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	}
+	m.refresh()
+	return nil
+}
+
+func (m *browseModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+	db := m.selected()
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-m.pageSize())
+	case "pgdown":
+		m.move(m.pageSize())
+	case "/":
+		m.filtering = true
+	case "esc":
+		m.filter = ""
+		m.refresh()
+	case "d":
+		if db != nil {
+			m.deleting = true
+			m.status = fmt.Sprintf("Delete %s? [y/N]", db.Path)
+		}
+	case "v":
+		if db != nil {
+			m.status = "Verifying " + db.Path
+			return m.verify(db)
+		}
+	case "u":
+		if db != nil {
+			m.status = "Unpacking " + db.Path
+			return m.unpack(db)
+		}
+	case "c":
+		if db != nil {
+			osc52.New(db.Path).WriteTo(os.Stderr)
+			m.status = "Copied " + db.Path
+		}
+	case "s":
+		if db != nil {
+			m.status = "Unpacking " + db.Path
+			return m.shell(db)
+		}
+	}
+	return nil
+}
+
+func (m *browseModel) verify(db *qldb.Database) tea.Cmd {
+	return func() tea.Msg {
+		result := m.store.Verify(db, qldb.VerifyOptions{})
+		if result.Err != nil {
+			return statusMsg(fmt.Sprintf("%s: %s: %v", db.Path, result.Status, result.Err))
+		}
+		return statusMsg(fmt.Sprintf("%s: %s", db.Path, result.Status))
+	}
+}
+
+func (m *browseModel) unpack(db *qldb.Database) tea.Cmd {
+	return func() tea.Msg {
+		path, err := m.store.Unpack(db)
+		if err != nil {
+			return statusMsg(err.Error())
+		}
+		return statusMsg("Unpacked to " + path)
+	}
+}
+
+// shell unpacks db in the background and then opens a shell in it.
+func (m *browseModel) shell(db *qldb.Database) tea.Cmd {
+	return func() tea.Msg {
+		path, err := m.store.Unpack(db)
+		if err != nil {
+			return statusMsg(err.Error())
+		}
+		return shellMsg(path)
+	}
+}
+
+func (m *browseModel) execShell(path string) tea.Cmd {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	c := exec.Command(shell)
+	c.Dir = path
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		if err != nil {
+			return statusMsg(err.Error())
+		}
+		return statusMsg("Back from " + path)
+	})
+}
+
+func (m *browseModel) delete() tea.Cmd {
+	db := m.selected()
+	if err := m.store.Remove(db.Path); err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	for i, e := range m.dbs {
+		if e == db {
+			m.dbs = append(m.dbs[:i], m.dbs[i+1:]...)
+			break
+		}
+	}
+	m.status = "Deleted " + db.Path
+	m.refresh()
+	return nil
+}
+
+// refresh rebuilds the tree rows from the databases matching the filter.
+func (m *browseModel) refresh() {
+	m.rows = nil
+	var owner, nwo string
+	for _, db := range m.dbs {
+		if !fuzzyMatch(m.filter, db.NWO+" "+db.Language+" "+db.ShortSha) {
+			continue
+		}
+		parts := strings.SplitN(db.NWO, "/", 2)
+		if parts[0] != owner {
+			owner = parts[0]
+			m.rows = append(m.rows, browseRow{depth: 0, label: owner})
+		}
+		if db.NWO != nwo {
+			nwo = db.NWO
+			m.rows = append(m.rows, browseRow{depth: 1, label: parts[len(parts)-1]})
+		}
+		m.rows = append(m.rows, browseRow{depth: 2, db: db})
+	}
+	m.cursor = 0
+	m.offset = 0
+	m.move(0)
+}
+
+// move moves the cursor by delta rows, skipping owner and repository rows.
+func (m *browseModel) move(delta int) {
+	if len(m.rows) == 0 {
+		return
+	}
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	target := m.cursor + delta
+	if target < 0 {
+		target = 0
+	}
+	if target >= len(m.rows) {
+		target = len(m.rows) - 1
+	}
+	for i := target; i >= 0 && i < len(m.rows); i += step {
+		if m.rows[i].db != nil {
+			m.cursor = i
+			break
+		}
+		// search the other way at the ends of the tree
+		if i+step < 0 || i+step >= len(m.rows) {
+			step = -step
+		}
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if page := m.pageSize(); m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+	// show the owner and repository of the first database
+	if m.offset > 0 && m.offset == m.cursor {
+		for m.offset > 0 && m.rows[m.offset-1].db == nil {
+			m.offset--
+		}
+	}
+}
+
+func (m *browseModel) selected() *qldb.Database {
+	if m.cursor < len(m.rows) {
+		return m.rows[m.cursor].db
+	}
+	return nil
+}
+
+func (m *browseModel) pageSize() int {
+	// header and status lines
+	if m.height > 4 {
+		return m.height - 4
+	}
+	return 20
+}
+
+func (m *browseModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-44s %-12s %-10s %-10s %8s\n", "DATABASE", "LANGUAGE", "SHA", "DATE", "SIZE")
+	end := m.offset + m.pageSize()
+	if end > len(m.rows) {
+		end = len(m.rows)
+	}
+	for i := m.offset; i < end; i++ {
+		row := m.rows[i]
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		if row.db == nil {
+			label := strings.Repeat("  ", row.depth) + row.label + "/"
+			fmt.Fprintf(&b, "%s%s\n", cursor, label)
+			continue
+		}
+		db := row.db
+		date := ""
+		if db.Metadata != nil && db.Metadata.CreationMetadata != nil {
+			date = db.Metadata.CreationMetadata.CreationTime.Format(dateLayout)
+		}
+		label := runewidth.FillRight(truncate("    "+filepath.Base(db.Path), 42), 42)
+		fmt.Fprintf(&b, "%s%s %-12s %-10s %-10s %8s\n", cursor, label, db.Language, db.ShortSha, date, utils.FormatSize(db.Size))
+	}
+	if len(m.rows) == 0 {
+		b.WriteString("  no databases\n")
+	}
+	b.WriteString("\n")
+	if m.filtering || m.filter != "" {
+		fmt.Fprintf(&b, "/%s", m.filter)
+		if m.filtering {
+			b.WriteString("_")
+		}
+		b.WriteString("  ")
+	}
+	if m.status != "" {
+		b.WriteString(m.status)
+	} else {
+		b.WriteString("d delete  v verify  u unpack  c copy path  s shell  / filter  q quit")
+	}
+	return b.String()
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in s in the
+// same order, ignoring case.
+func fuzzyMatch(pattern string, s string) bool {
+	p := []rune(strings.ToLower(pattern))
+	i := 0
+	for _, r := range strings.ToLower(s) {
+		if i < len(p) && p[i] == r {
+			i++
+		}
+	}
+	return i == len(p)
+}
+
+// truncate shortens s to n terminal columns, cutting between runes.
+func truncate(s string, n int) string {
+	return runewidth.Truncate(s, n, "…")
+}
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -15,7 +19,22 @@ var createCmd = &cobra.Command{
 	Short: "Extracts a CodeQL database from a source path",
 	Long: `Extracts a CodeQL database from a source path. Pass the CodeQL arguments after a '--' separator.
 
-eg: gh-qldb create --nwo foo/bar -- -s /path/to/src -l javascript`,
+eg: gh-qldb create --nwo foo/bar -- -s /path/to/src -l javascript
+
+Every database of a database cluster is installed, eg:
+gh-qldb create --nwo foo/bar -- -s /path/to/src --db-cluster -l java -l python
+
+When the source root is a git working tree, the NWO is inferred from the
+origin remote, or the one chosen with --remote, and the HEAD commit is recorded
+if CodeQL does not record one.
+
+With --repo, a repository URL, local path or NWO, the repository is
+shallow cloned at --ref into a temporary workspace that is used as the source
+root, eg:
+gh-qldb create --repo apache/logging-log4j2 --ref rel/2.14.1 -- -l java
+
+The build profiles in the configuration file are applied to the CodeQL
+arguments, which override the profile options they repeat.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// --nwo foo/bar -- -s /path/to/src -l javascript
 		create(nwoFlag, args)
@@ -25,24 +44,137 @@ eg: gh-qldb create --nwo foo/bar -- -s /path/to/src -l javascript`,
 func init() {
 	rootCmd.AddCommand(createCmd)
 	createCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to create the database for. If omitted, it will be inferred from git remotes.")
+	createCmd.Flags().StringVar(&remoteFlag, "remote", "origin", "The git remote to infer the NWO from.")
+	createCmd.Flags().StringVar(&profileFlag, "profile", "", "The build profile to use. Defaults to the profile matching the NWO and language.")
+	createCmd.Flags().BoolVar(&noProfileFlag, "no-profile", false, "Do not apply any build profile.")
+	createCmd.Flags().StringVar(&repoFlag, "repo", "", "The URL, local path or NWO of a repository to clone and create the database from.")
+	createCmd.Flags().StringVar(&refFlag, "ref", "", "The commit SHA, tag or branch to check out with --repo. Defaults to the default branch.")
+	createCmd.MarkFlagsMutuallyExclusive("profile", "no-profile")
+	createCmd.MarkFlagsMutuallyExclusive("repo", "remote")
 }
 
 func create(nwo string, codeqlArgs []string) {
-	fmt.Printf("Creating DB for '%s'. CodeQL args: '%v'", nwo, codeqlArgs)
-	destPath := filepath.Join(os.TempDir(), "codeql-db")
-	if err := os.MkdirAll(destPath, 0755); err != nil {
+	store := newStore()
+	nwo, results, err := createDatabases(store, nwo, codeqlArgs)
+	if err != nil {
 		log.Fatal(err)
 	}
-	args := []string{"database", "create"}
-	args = append(args, codeqlArgs...)
-	args = append(args, "--")
-	args = append(args, destPath)
-	cmd := exec.Command("codeql", args...)
-	cmd.Env = os.Environ()
-	_, err := cmd.CombinedOutput()
+
+	// print a summary of the installed databases
+	var rows []summaryRow
+	for _, r := range results {
+		row := summaryRow{nwo: nwo, language: r.Language, status: "installed", err: r.Err}
+		if r.Err != nil {
+			row.status = "failed"
+		} else {
+			row.path = r.Database.Path
+		}
+		rows = append(rows, row)
+	}
+	printSummary(rows)
+}
+
+// createDatabases creates the databases and returns the NWO they were
+// installed under.
+func createDatabases(store *qldb.Store, nwo string, codeqlArgs []string) (string, []*qldb.CreateResult, error) {
+	if repoFlag != "" {
+		if sourceRootArg(codeqlArgs) != "." {
+			return "", nil, errors.New("the source root cannot be set with --repo")
+		}
+		url, host, repoNwo, err := repoURL(store.Host, repoFlag)
+		if err != nil {
+			return "", nil, err
+		}
+		workspace, err := os.MkdirTemp("", "qldb-workspace-")
+		if err != nil {
+			return "", nil, err
+		}
+		defer os.RemoveAll(workspace)
+		fmt.Printf("Cloning '%s' into '%s'\n", url, workspace)
+		if err := utils.GitClone(url, refFlag, workspace); err != nil {
+			return "", nil, err
+		}
+		if nwo == "" {
+			if host != "" {
+				store.Host = host
+			}
+			nwo = repoNwo
+		}
+		codeqlArgs = append([]string{"-s", workspace}, codeqlArgs...)
+	}
+
+	sourceRoot := sourceRootArg(codeqlArgs)
+	commitSha, err := utils.GitHead(sourceRoot)
+	if err == nil {
+		if dirty, err := utils.GitDirty(sourceRoot); err == nil && dirty {
+			fmt.Fprintf(os.Stderr, "Warning: '%s' has uncommitted changes, the database will not match commit %s\n", sourceRoot, commitSha)
+		}
+	}
+	if nwo == "" {
+		host, remoteNwo, err := utils.GitRemote(sourceRoot, remoteFlag)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot infer the NWO, use --nwo: %w", err)
+		}
+		store.Host = host
+		nwo = remoteNwo
+	}
+
+	opts := qldb.CreateOptions{Args: codeqlArgs, CommitSha: commitSha}
+	if !noProfileFlag {
+		config, err := qldb.LoadConfig()
+		if err != nil {
+			return "", nil, err
+		}
+		if opts.Profile, err = config.Profile(profileFlag, nwo, codeqlArgs); err != nil {
+			return "", nil, err
+		}
+		if opts.Profile != nil {
+			fmt.Printf("Using build profile '%s'\n", opts.Profile.Name)
+		}
+	}
+	results, err := store.Create(nwo, opts)
+	return nwo, results, err
+}
+
+// repoURL returns the URL to clone for repo, a repository URL, local path or
+// NWO on host, along with the host and NWO of the repository. The host is
+// empty for local repositories and NWOs.
+func repoURL(host string, repo string) (string, string, string, error) {
+	path := strings.TrimPrefix(repo, "file://")
+	if _, err := os.Stat(path); err == nil {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", "", "", err
+		}
+		// the NWO of local repositories is taken from their last two path
+		// elements, eg: /srv/git/foo/bar.git
+		nwo := filepath.Base(filepath.Dir(abs)) + "/" + strings.TrimSuffix(filepath.Base(abs), ".git")
+		return "file://" + filepath.ToSlash(abs), "", nwo, nil
+	}
+	if nwoPattern.MatchString(repo) {
+		return fmt.Sprintf("https://%s/%s.git", host, repo), "", repo, nil
+	}
+	repoHost, nwo, err := utils.ParseGitURL(repo)
 	if err != nil {
-		log.Fatalln(err)
+		return "", "", "", err
 	}
+	return repo, repoHost, nwo, nil
+}
 
-	install(nwo, destPath, true)
+var nwoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// sourceRootArg returns the source root passed to CodeQL in args, which
+// defaults to the current directory.
+func sourceRootArg(args []string) string {
+	for i, arg := range args {
+		switch {
+		case (arg == "-s" || arg == "--source-root") && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--source-root="):
+			return strings.TrimPrefix(arg, "--source-root=")
+		case strings.HasPrefix(arg, "-s") && !strings.HasPrefix(arg, "--") && len(arg) > 2:
+			return arg[2:]
+		}
+	}
+	return "."
 }
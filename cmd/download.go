@@ -1,24 +1,30 @@
 package cmd
 
 import (
-	"encoding/json"
-	"errors"
+	"bufio"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"strings"
 
-	"github.com/GitHubSecurityLab/gh-qldb/utils"
-	"github.com/cli/go-gh"
-	"github.com/cli/go-gh/pkg/api"
 	"github.com/spf13/cobra"
 )
 
+var (
+	nwoListFlag      []string
+	nwoFileFlag      string
+	languageListFlag []string
+	jobsFlag         int
+)
+
 var downloadCmd = &cobra.Command{
 	Use:   "download",
 	Short: "Downloads a CodeQL database from GitHub Code Scanning",
-	Long:  `Downloads a CodeQL database from GitHub Code Scanning`,
+	Long: `Downloads CodeQL databases from GitHub Code Scanning.
+
+Several repositories and languages can be downloaded at once, eg:
+gh-qldb download -n foo/bar -n foo/baz -l java -l python --jobs 4
+gh-qldb download --nwo-file repos.txt -l all`,
 	Run: func(cmd *cobra.Command, args []string) {
 		download()
 	},
@@ -26,119 +32,79 @@ var downloadCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(downloadCmd)
-	downloadCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to download the database for.")
-	downloadCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language you want the database for.")
-	downloadCmd.MarkFlagRequired("nwo")
+	downloadCmd.Flags().StringSliceVarP(&nwoListFlag, "nwo", "n", nil, "The NWO of the repository to download the database for. Can be repeated.")
+	downloadCmd.Flags().StringVar(&nwoFileFlag, "nwo-file", "", "A file with one NWO per line to download the databases for.")
+	downloadCmd.Flags().StringSliceVarP(&languageListFlag, "language", "l", nil, "The primary language you want the database for, or 'all'. Can be repeated.")
+	downloadCmd.Flags().IntVar(&jobsFlag, "jobs", 4, "The number of databases to download at the same time.")
+	downloadCmd.MarkFlagsOneRequired("nwo", "nwo-file")
 	downloadCmd.MarkFlagRequired("language")
-
 }
 
 func download() {
-	// fetch the DB info from GitHub API
-	fmt.Printf("Fetching DB info for '%s'\n", nwoFlag)
-	restClient, err := gh.RESTClient(nil)
-	response := make([]interface{}, 0)
-	err = restClient.Get(fmt.Sprintf("repos/%s/code-scanning/codeql/databases", nwoFlag), &response)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Print("Found DBs for the following languages: ")
-	for i, v := range response {
-		dbMap := v.(map[string]interface{})
-		language := dbMap["language"].(string)
-		fmt.Print(language)
-		if i < len(response)-1 {
-			fmt.Print(", ")
-		}
-	}
-	fmt.Print("\n")
-
-	// download the DBs
-	for _, v := range response {
-		dbMap := v.(map[string]interface{})
-		language := dbMap["language"].(string)
-		if languageFlag != "all" && language != languageFlag {
-			continue
-		}
-
-		// download DB
-		fmt.Printf("Downloading '%s' DB for '%s'\n", language, nwoFlag)
-		opts := api.ClientOptions{
-			Headers: map[string]string{"Accept": "application/zip"},
-		}
-		httpClient, err := gh.HTTPClient(&opts)
-		if err != nil {
-			log.Fatal(err)
-		}
-		url := fmt.Sprintf("https://api.github.com/repos/%s/code-scanning/codeql/databases/%s", nwoFlag, language)
-		resp, err := httpClient.Get(url)
-		if err != nil {
-			fmt.Printf("Failure downloading the DB from `%s`: %v", url, err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
+	nwos := nwoListFlag
+	if nwoFileFlag != "" {
+		fileNwos, err := readNwoFile(nwoFileFlag)
 		if err != nil {
 			log.Fatal(err)
 		}
+		nwos = append(nwos, fileNwos...)
+	}
+	nwos = unique(nwos)
 
-		// get the commit this DB was created from
-		metadata, err := utils.ExtractDBInfo(body)
-		if err != nil {
-			log.Fatal(err)
+	var languages []string
+	for _, language := range languageListFlag {
+		if language == "all" {
+			languages = nil
+			break
 		}
-		metadata["provenance"] = nwoFlag
-		commitSha := metadata["creationMetadata"].(map[string]interface{})["sha"].(string)
-		shortCommitSha := commitSha[:8]
-		primaryLanguage := metadata["primaryLanguage"].(string)
-		fmt.Println()
-		fmt.Println("Commit SHA:", commitSha)
-		fmt.Println("Short Commit SHA:", shortCommitSha)
-		fmt.Println("Primary language:", primaryLanguage)
+		languages = append(languages, language)
+	}
 
-		zipFilename := fmt.Sprintf("%s-%s.zip", primaryLanguage, shortCommitSha)
-		jsonFilename := fmt.Sprintf("%s-%s.json", primaryLanguage, shortCommitSha)
-		dir := utils.GetPath(nwoFlag)
-		zipPath := filepath.Join(dir, zipFilename)
-		jsonPath := filepath.Join(dir, jsonFilename)
+	store := newStore()
+	fmt.Printf("Fetching DBs for %d repositories\n", len(nwos))
+	results := store.DownloadAll(nwos, languages, jobsFlag)
 
-		// create directory if not exists
-		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
-			err := os.MkdirAll(dir, 0755)
-			if err != nil {
-				log.Fatal(err)
-			}
+	// print a summary of the downloads
+	var rows []summaryRow
+	for _, r := range results {
+		row := summaryRow{nwo: r.NWO, language: r.Language, status: string(r.Status), err: r.Err}
+		if r.Database != nil {
+			row.path = r.Database.Path
 		}
+		rows = append(rows, row)
+	}
+	printSummary(rows)
+	fmt.Println("Done")
+}
 
-		// create DB file if doesnot exists
-		if _, err := os.Stat(zipPath); errors.Is(err, os.ErrNotExist) {
-			// write the DB to disk
-			err = os.WriteFile(zipPath, body, 0755)
-			if err != nil {
-				log.Fatal(err)
-			}
-			fmt.Printf("Writing DB to %s\n", zipPath)
-
-		} else {
-			fmt.Printf("Aborting, DB %s already exists\n", zipPath)
+// readNwoFile reads a file with one NWO per line. Empty lines and lines
+// starting with '#' are ignored.
+func readNwoFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var nwos []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
+		nwos = append(nwos, line)
+	}
+	return nwos, scanner.Err()
+}
 
-		// create Metadata file if doesnot exists
-		if _, err := os.Stat(jsonPath); errors.Is(err, os.ErrNotExist) {
-			// Convert the map to JSON
-			jsonData, err := json.Marshal(metadata)
-			if err != nil {
-				log.Fatal(err)
-			}
-			// Write the JSON data to a file
-			err = os.WriteFile(jsonPath, jsonData, 0644)
-			if err != nil {
-				log.Fatal(err)
-			}
-		} else {
-			fmt.Printf("Aborting, DB metadata %s already exists\n", jsonPath)
+func unique(list []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, e := range list {
+		if !seen[e] {
+			seen[e] = true
+			result = append(result, e)
 		}
 	}
-	fmt.Println("Done")
+	return result
 }
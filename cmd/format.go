@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/cli/go-gh/pkg/jq"
+	"github.com/cli/go-gh/pkg/tableprinter"
+	"github.com/cli/go-gh/pkg/template"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+// dateLayout is the layout of the dates printed in tables and accepted by
+// date flags.
+const dateLayout = "2006-01-02"
+
+// addFormatFlags adds the --format and --jq flags to cmd.
+func addFormatFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&formatFlag, "format", "f", "", "Use \"table\" or a Go template applied to the json output as the output format.")
+	cmd.Flags().StringVarP(&jqFlag, "jq", "q", "", "Filter the json output using a jq expression.")
+	cmd.MarkFlagsMutuallyExclusive("format", "jq")
+}
+
+// printOutput prints the output of a command using the format selected by
+// the --json, --format and --jq flags. data is the json output, the --format
+// templates and --jq expressions are applied to it. The default output is
+// printed by plain.
+func printOutput(data interface{}, dbs []*qldb.Database, plain func()) {
+	switch {
+	case jqFlag != "":
+		if err := jq.Evaluate(jsonReader(data), os.Stdout, jqFlag); err != nil {
+			log.Fatal(err)
+		}
+	case formatFlag == "table":
+		printTable(dbs)
+	case formatFlag != "":
+		terminal := term.FromEnv()
+		width, _, _ := terminal.Size()
+		t := template.New(os.Stdout, width, terminal.IsColorEnabled())
+		if err := t.Parse(formatFlag); err != nil {
+			log.Fatal(err)
+		}
+		if err := t.Execute(jsonReader(data)); err != nil {
+			log.Fatal(err)
+		}
+		if err := t.Flush(); err != nil {
+			log.Fatal(err)
+		}
+	case jsonFlag:
+		printJSON(data)
+	default:
+		plain()
+	}
+}
+
+func jsonReader(data interface{}) *bytes.Reader {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return bytes.NewReader(jsonBytes)
+}
+
+// printTable prints dbs as a column aligned table. The date is the commit
+// date when it is known and the creation date of the database otherwise.
+func printTable(dbs []*qldb.Database) {
+	isTTY := term.IsTerminal(os.Stdout)
+	width, _, _ := term.FromEnv().Size()
+	table := tableprinter.New(os.Stdout, isTTY, width)
+	if isTTY {
+		table.AddField("NWO")
+		table.AddField("LANGUAGE")
+		table.AddField("SHA")
+		table.AddField("DATE")
+		table.AddField("SIZE")
+		table.AddField("SOURCE")
+		table.EndRow()
+	}
+	for _, db := range dbs {
+		date := db.CommittedDate
+		source := ""
+		if db.Metadata != nil {
+			if date == "" && db.Metadata.CreationMetadata != nil {
+				date = db.Metadata.CreationMetadata.CreationTime.Format(dateLayout)
+			}
+			source = db.Metadata.Provenance
+		}
+		// committed dates include the time of the commit
+		if len(date) > len(dateLayout) {
+			date = date[:len(dateLayout)]
+		}
+		table.AddField(db.NWO)
+		table.AddField(db.Language)
+		table.AddField(db.ShortSha)
+		table.AddField(date)
+		table.AddField(utils.FormatSize(db.Size))
+		table.AddField(source)
+		table.EndRow()
+	}
+	if err := table.Render(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// summaryRow is the outcome of creating or downloading a database.
+type summaryRow struct {
+	nwo      string
+	language string
+	status   string
+	path     string
+	err      error
+}
+
+// printSummary prints the outcome of creating or downloading databases as a
+// column aligned table and exits with status 1 if any of them failed.
+func printSummary(rows []summaryRow) {
+	fmt.Println()
+	isTTY := term.IsTerminal(os.Stdout)
+	width, _, _ := term.FromEnv().Size()
+	table := tableprinter.New(os.Stdout, isTTY, width)
+	if isTTY {
+		table.AddField("NWO")
+		table.AddField("LANGUAGE")
+		table.AddField("STATUS")
+		table.AddField("DETAILS")
+		table.EndRow()
+	}
+	failed := 0
+	for _, r := range rows {
+		details := r.path
+		if r.err != nil {
+			failed++
+			details = r.err.Error()
+		}
+		table.AddField(r.nwo)
+		table.AddField(r.language)
+		table.AddField(r.status)
+		table.AddField(details)
+		table.EndRow()
+	}
+	if err := table.Render(); err != nil {
+		log.Fatal(err)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
 	"github.com/GitHubSecurityLab/gh-qldb/utils"
 	"github.com/spf13/cobra"
 )
@@ -15,7 +15,9 @@ import (
 var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Returns information about a database stored in the QLDB structure",
-	Long:  `Returns information about a database stored in the QLDB structure`,
+	Long: `Returns information about a database stored in the QLDB structure.
+
+The information is read from the local metadata, GitHub is only contacted when --resolve-remote is set.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		info()
 	},
@@ -27,89 +29,72 @@ func init() {
 	infoCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language you want the database for.")
 	infoCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Use json as the output format.")
 	infoCmd.Flags().StringVarP(&dbPathFlag, "db-path", "p", "", "Path to the database to get the info from.")
+	addFormatFlags(infoCmd)
+	infoCmd.Flags().BoolVar(&resolveRemoteFlag, "resolve-remote", false, "Resolve the commit date using the GitHub API.")
 	infoCmd.MarkFlagsOneRequired("db-path", "nwo")
 	infoCmd.MarkFlagsMutuallyExclusive("db-path", "nwo")
 }
 
 func info() {
-	var results []map[string]string
-
+	store := newStore()
+	// keep stdout for the output only
+	store.Log = os.Stderr
+	opts := qldb.InfoOptions{ResolveRemote: resolveRemoteFlag}
+	var dbs []*qldb.Database
 	if nwoFlag != "" {
-		results = infoFromNwo(nwoFlag)
+		opts.Language = languageFlag
+		var err error
+		if dbs, err = store.InfoForNWO(nwoFlag, opts); err != nil {
+			log.Fatal(err)
+		}
 	} else if dbPathFlag != "" {
-		results = append(results, infoFromPath(dbPathFlag))
-	}
-	if jsonFlag {
-		jsonStr, err := json.MarshalIndent(results, "", "  ")
+		db, err := store.Info(dbPathFlag, opts)
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("%s", jsonStr)
-	} else {
-		for _, result := range results {
-			fmt.Println(result["path"])
-		}
+		dbs = append(dbs, db)
 	}
-}
-
-func infoFromPath(path string) map[string]string {
-	// get the file name part of path
-	parts := strings.Split(path, string(os.PathSeparator))
-	name := parts[len(parts)-1]
-	base := filepath.Dir(path)
 
-	var dbname string
-	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
-		dbname = path
-	} else if filepath.Ext(name) == ".zip" {
-		dbname = name[:len(name)-len(filepath.Ext(name))]
-	} else {
-		log.Fatal(fmt.Errorf("invalid database path: %s", path))
-		return nil
+	results := []*qldb.DatabaseRecord{}
+	for _, db := range dbs {
+		results = append(results, db.Record())
 	}
+	printOutput(results, dbs, func() {
+		for _, db := range dbs {
+			printInfo(db)
+		}
+	})
+}
 
-	// split the name by the "-". first element is the language, second is the short commit sha
-	nameSplit := strings.Split(dbname, "-")
-	if len(nameSplit) != 2 {
-		log.Fatal(fmt.Errorf("invalid database name: %s", name))
-		return nil
+// printInfo prints the information about a database in a human readable
+// form.
+func printInfo(db *qldb.Database) {
+	fmt.Println(db.Path)
+	fmt.Printf("  NWO:            %s\n", db.NWO)
+	fmt.Printf("  Language:       %s\n", db.Language)
+	fmt.Printf("  Commit SHA:     %s\n", db.CommitSha)
+	if db.CommittedDate != "" {
+		fmt.Printf("  Committed date: %s\n", db.CommittedDate)
 	}
-
-	lang := nameSplit[0]
-	shortSha := nameSplit[1]
-	baseParts := strings.Split(base, string(os.PathSeparator))
-	nwo := filepath.Join(baseParts[len(baseParts)-2], baseParts[len(baseParts)-1])
-	commitSha, committedDate, err := utils.GetCommitInfo2(nwo, shortSha)
-	if err != nil {
-		log.Fatal(err)
+	fmt.Printf("  Size:           %s\n", utils.FormatSize(db.Size))
+	if db.Metadata == nil {
+		return
 	}
-
-	return map[string]string{
-		"commitSha":     commitSha,
-		"committedDate": committedDate,
-		"language":      lang,
-		"path":          path,
+	if db.Metadata.CreationMetadata != nil {
+		fmt.Printf("  CLI version:    %s\n", db.Metadata.CreationMetadata.CliVersion)
+		fmt.Printf("  Created:        %s\n", db.Metadata.CreationMetadata.CreationTime.Format(time.RFC3339))
 	}
-}
-
-func infoFromNwo(nwo string) []map[string]string {
-	dir := utils.GetPath(nwo)
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		log.Fatal(err)
+	fmt.Printf("  Lines of code:  %d\n", db.Metadata.BaselineLinesOfCode)
+	if db.Metadata.Provenance != "" {
+		fmt.Printf("  Provenance:     %s\n", db.Metadata.Provenance)
 	}
-	var pathList []string
-
-	for _, e := range entries {
-		entryName := e.Name()
-		if filepath.Ext(entryName) == ".zip" || e.IsDir() {
-			pathList = append(pathList, filepath.Join(dir, entryName))
+	if b := db.Metadata.Build; b != nil {
+		if b.Profile != "" {
+			fmt.Printf("  Build profile:  %s\n", b.Profile)
 		}
+		fmt.Printf("  Build args:     %s\n", strings.Join(b.Args, " "))
 	}
-
-	var results []map[string]string
-	for _, path := range pathList {
-		results = append(results, infoFromPath(path))
+	for _, r := range db.Metadata.Results {
+		fmt.Printf("  Results:        %s (CodeQL %s, %s)\n", r.Name, r.CliVersion, r.Timestamp.Format(time.RFC3339))
 	}
-	return results
 }
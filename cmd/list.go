@@ -1,21 +1,22 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
-	"strings"
 
-	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
 	"github.com/spf13/cobra"
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Returns a list of CodeQL databases stored in the QLDB structure",
-	Long:  `Returns a list of CodeQL databases stored in the QLDB structure`,
+	Long: `Returns a list of CodeQL databases stored in the QLDB structure.
+
+The NWO is matched exactly, ignoring case, or as a glob pattern, eg:
+gh-qldb list --nwo 'apache/*' -l java -l javascript
+gh-qldb list --owner apache --sha fa2f`,
 	Run: func(cmd *cobra.Command, args []string) {
 		list()
 	},
@@ -23,79 +24,43 @@ var listCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(listCmd)
-	listCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to get the databases for.")
-	listCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language you want the databases for.")
+	listCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO, or glob pattern, of the repositories to get the databases for.")
+	listCmd.Flags().StringVarP(&ownerFlag, "owner", "o", "", "The owner of the repositories to get the databases for.")
+	listCmd.Flags().StringSliceVarP(&languageListFlag, "language", "l", nil, "The primary languages you want the databases for. Can be repeated.")
+	listCmd.Flags().StringVarP(&shaFlag, "sha", "s", "", "The commit SHA, or SHA prefix, of the databases.")
 	listCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Use json as the output format.")
+	addFormatFlags(listCmd)
 }
 
 func list() {
-	var results []string
-	basePath := utils.GetBasePath()
-	dirEntries, err := os.ReadDir(basePath)
+	store := newStore()
+	// keep stdout for the output only
+	store.Log = os.Stderr
+	dbs, err := store.List(qldb.ListOptions{
+		NWO:       nwoFlag,
+		Owner:     ownerFlag,
+		Languages: languageListFlag,
+		Sha:       shaFlag,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
-	for _, dirEntry := range dirEntries {
-		if dirEntry.IsDir() {
-			user := dirEntry.Name()
-			userPath := filepath.Join(basePath, user)
-			repoEntries, err := os.ReadDir(userPath)
-			if err != nil {
-				log.Fatal(err)
-			}
-			for _, repoEntry := range repoEntries {
-				if repoEntry.IsDir() {
-					repo := repoEntry.Name()
-					nwoPath := filepath.Join(userPath, repo)
-					dbEntries, err := os.ReadDir(nwoPath)
-					if err != nil {
-						log.Fatal(err)
-					}
-					for _, dbEntry := range dbEntries {
-						if dbEntry.IsDir() || filepath.Ext(dbEntry.Name()) == ".zip" {
-							dbPath := filepath.Join(nwoPath, dbEntry.Name())
-							results = append(results, dbPath)
-						}
-					}
-				}
+	warnUnrecognized(store)
+	// everything but the plain list of paths needs the size of the databases
+	if jsonFlag || formatFlag != "" || jqFlag != "" {
+		for i, db := range dbs {
+			if info, err := store.Info(db.Path, qldb.InfoOptions{}); err == nil {
+				dbs[i] = info
 			}
 		}
 	}
-
-	// if languageFlag is set, filter the results
-	// so that the entries in results only contains elements which filename starts with languageFlag-
-	if languageFlag != "" {
-		var filteredResults []string
-		for _, result := range results {
-			if strings.HasPrefix(filepath.Base(result), languageFlag+"-") {
-				filteredResults = append(filteredResults, result)
-			}
-		}
-		results = filteredResults
+	results := []*qldb.DatabaseRecord{}
+	for _, db := range dbs {
+		results = append(results, db.Record())
 	}
-
-	// if nwoFlag is set, filter the results so that the results only contains elements which contains nwoFlag
-	if nwoFlag != "" {
-		var filteredResults []string
-		for _, result := range results {
-			if strings.Contains(strings.ToLower(result), strings.ToLower(nwoFlag)) {
-				filteredResults = append(filteredResults, result)
-			}
+	printOutput(results, dbs, func() {
+		for _, db := range dbs {
+			fmt.Println(db.Path)
 		}
-		results = filteredResults
-	}
-
-	// if jsonFlag is set, print the results as json
-	if jsonFlag {
-		jsonBytes, err := json.MarshalIndent(results, "", "  ")
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Println(string(jsonBytes))
-	} else {
-		for _, result := range results {
-			fmt.Println(result)
-		}
-	}
-
+	})
 }
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	atFlag       string
+	unpackedFlag bool
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Returns the path of a single CodeQL database stored in the QLDB structure",
+	Long: `Returns the path of a single CodeQL database stored in the QLDB structure.
+
+The newest database by creation time is returned unless a commit SHA is given. Exits with a non-zero status when no database, or more than one, matches.
+
+eg: gh-qldb path --nwo apache/logging-log4j2 --language java`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+	pathCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to get the database for.")
+	pathCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language you want the database for.")
+	pathCmd.Flags().StringVarP(&shaFlag, "sha", "s", "", "The commit SHA, or SHA prefix, of the database.")
+	pathCmd.Flags().StringVar(&atFlag, "at", "", "Return the newest database created at or before this date (YYYY-MM-DD or RFC 3339).")
+	pathCmd.Flags().BoolVarP(&unpackedFlag, "unpacked", "u", false, "Return the path of the unpacked database, unpacking it if needed.")
+	pathCmd.MarkFlagRequired("nwo")
+	pathCmd.MarkFlagsMutuallyExclusive("sha", "at")
+}
+
+func path() {
+	store := newStore()
+	// keep stdout for the path only
+	store.Log = os.Stderr
+	db := resolveDatabase(store)
+	if unpackedFlag {
+		fmt.Println(unpack(store, db))
+		return
+	}
+	fmt.Println(db.Path)
+}
+
+// resolveDatabase returns the database selected by the --db-path, or the
+// --nwo, --language, --sha and --at flags.
+func resolveDatabase(store *qldb.Store) *qldb.Database {
+	if dbPathFlag != "" {
+		path, err := filepath.Abs(dbPathFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if db, err := store.Info(path, qldb.InfoOptions{}); err == nil {
+			return db
+		}
+		return &qldb.Database{Path: path}
+	}
+	opts := qldb.ResolveOptions{
+		NWO:      nwoFlag,
+		Language: languageFlag,
+		Sha:      shaFlag,
+	}
+	if atFlag != "" {
+		at, err := parseDate(atFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.At = at
+	}
+	db, err := store.Resolve(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db
+}
+
+// parseDate parses a date or an RFC 3339 timestamp. A date refers to the end
+// of that day in UTC.
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", s)
+	}
+	return t.Add(24*time.Hour - time.Nanosecond), nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keepFlag      int
+	olderThanFlag string
+	maxSizeFlag   string
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Removes old CodeQL databases from the QLDB structure",
+	Long: `Removes old CodeQL databases from the QLDB structure according to a retention policy.
+
+Databases are ordered by the creation time stored in their metadata.
+
+eg: gh-qldb prune --keep 3 --older-than 90d --max-size 50GB`,
+	Run: func(cmd *cobra.Command, args []string) {
+		prune()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().IntVarP(&keepFlag, "keep", "k", 0, "The number of databases to keep per repository and language.")
+	pruneCmd.Flags().StringVar(&olderThanFlag, "older-than", "", "Remove databases older than this age, eg: 90d, 12w or 36h.")
+	pruneCmd.Flags().StringVar(&maxSizeFlag, "max-size", "", "Remove the oldest databases until the total size fits this budget, eg: 50GB.")
+	pruneCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Print the databases that would be removed without removing them.")
+	pruneCmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Do not ask for confirmation.")
+	pruneCmd.MarkFlagsOneRequired("keep", "older-than", "max-size")
+}
+
+func prune() {
+	opts := qldb.PruneOptions{Keep: keepFlag}
+	if olderThanFlag != "" {
+		age, err := parseAge(olderThanFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.OlderThan = age
+	}
+	if maxSizeFlag != "" {
+		size, err := utils.ParseSize(maxSizeFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.MaxSize = size
+	}
+
+	store := newStore()
+	candidates, err := store.Prune(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(candidates) == 0 {
+		fmt.Println("Nothing to prune")
+		return
+	}
+
+	var total int64
+	for _, c := range candidates {
+		total += c.Size
+		fmt.Printf("%s\t%s\t%s\n", c.CreatedAt.Format(time.RFC3339), utils.FormatSize(c.Size), c.Path)
+	}
+	if dryRunFlag {
+		fmt.Printf("Would remove %d database(s), %s\n", len(candidates), utils.FormatSize(total))
+		return
+	}
+	if !yesFlag {
+		ok, err := confirm(fmt.Sprintf("Remove %d database(s), %s?", len(candidates), utils.FormatSize(total)))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			fmt.Println("Aborting")
+			return
+		}
+	}
+	for _, c := range candidates {
+		if err := store.Remove(c.Path); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// parseAge parses a duration that, in addition to the time.ParseDuration
+// units, accepts days (d) and weeks (w).
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age: %s", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age: %s", s)
+	}
+	return d, nil
+}
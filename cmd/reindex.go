@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuilds the local index of CodeQL databases",
+	Long: `Rebuilds the local index used by list and info from the databases and metadata files stored in the QLDB structure.
+
+Run it after adding or removing databases by hand.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		reindex()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+}
+
+func reindex() {
+	store := newStore()
+	dbs, err := store.Reindex()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Indexed %d database(s) in %s\n", len(dbs), store.IndexPath())
+	warnUnrecognized(store)
+}
+
+// warnUnrecognized reports the entries of the QLDB structure that are not
+// named like databases on stderr.
+func warnUnrecognized(store *qldb.Store) {
+	paths, err := store.Unrecognized()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range paths {
+		fmt.Fprintf(os.Stderr, "Skipping unrecognized entry: %s\n", path)
+	}
+}
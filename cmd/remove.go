@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/cli/go-gh/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes CodeQL databases from the QLDB structure",
+	Long: `Removes CodeQL databases from the QLDB structure, together with their metadata. The databases are selected by --db-path or by any combination of --nwo, --language and --sha.
+
+eg: gh-qldb remove --nwo foo/bar --language java --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		remove()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+	removeCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to remove the databases for.")
+	removeCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language of the databases to remove.")
+	removeCmd.Flags().StringVarP(&shaFlag, "sha", "s", "", "The commit SHA, or SHA prefix, of the databases to remove.")
+	removeCmd.Flags().StringVarP(&dbPathFlag, "db-path", "p", "", "Path to the database to remove.")
+	removeCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Print the databases that would be removed without removing them.")
+	removeCmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Do not ask for confirmation.")
+	removeCmd.MarkFlagsOneRequired("db-path", "nwo", "language", "sha")
+	removeCmd.MarkFlagsMutuallyExclusive("db-path", "nwo")
+	removeCmd.MarkFlagsMutuallyExclusive("db-path", "language")
+	removeCmd.MarkFlagsMutuallyExclusive("db-path", "sha")
+}
+
+func remove() {
+	store := newStore()
+	var paths []string
+	if dbPathFlag != "" {
+		path, err := filepath.Abs(dbPathFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		paths = append(paths, path)
+	} else {
+		dbs, err := store.List(qldb.ListOptions{
+			NWO:      nwoFlag,
+			Language: languageFlag,
+			Sha:      shaFlag,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, db := range dbs {
+			paths = append(paths, db.Path)
+		}
+	}
+	if len(paths) == 0 {
+		log.Fatal(qldb.ErrNotFound)
+	}
+
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	if dryRunFlag {
+		fmt.Printf("Would remove %d database(s)\n", len(paths))
+		return
+	}
+	if !yesFlag {
+		ok, err := confirm(fmt.Sprintf("Remove %d database(s)?", len(paths)))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			fmt.Println("Aborting")
+			return
+		}
+	}
+	for _, path := range paths {
+		if err := store.Remove(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// confirm asks the user a yes/no question on the terminal.
+func confirm(question string) (bool, error) {
+	if !term.IsTerminal(os.Stdin) {
+		return false, errors.New("not running in a terminal, use --yes to confirm")
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queriesFlag []string
+	sarifFlag   string
+)
+
+var resultsCmd = &cobra.Command{
+	Use:   "results",
+	Short: "Manages the analysis results stored with CodeQL databases",
+	Long: `Manages the analysis results stored with CodeQL databases.
+
+The SARIF files are stored in a <language>-<sha>.results directory next to each database, and the analyses are recorded in the database metadata.`,
+}
+
+var resultsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Stores a SARIF file as the results of an analysis of a database",
+	Long: `Stores a SARIF file as the results of an analysis of a database.
+
+eg: gh-qldb results add --nwo foo/bar --language java --sarif results.sarif --name security-extended`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := newStore()
+		db := resolveDatabase(store)
+		result, err := store.AddResult(db, sarifFlag, nameFlag, queriesFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(qldb.SarifPath(db.Path, result.Name))
+	},
+}
+
+var resultsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the analysis results stored with a database",
+	Long:  `Lists the analysis results stored with a database`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := newStore()
+		db := resolveDatabase(store)
+		results, err := store.Results(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if jsonFlag {
+			printJSON(results)
+			return
+		}
+		for _, r := range results {
+			fmt.Printf("%s\t%s\t%s\t%s\n", r.Name, r.CliVersion, r.Timestamp.Format(time.RFC3339), qldb.SarifPath(db.Path, r.Name))
+		}
+	},
+}
+
+var resultsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Shows the analysis results stored with a database",
+	Long:  `Shows the alerts found by an analysis of a database, or the SARIF file itself with --json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := newStore()
+		db := resolveDatabase(store)
+		sarifPath := qldb.SarifPath(db.Path, nameFlag)
+		if jsonFlag {
+			data, err := os.ReadFile(sarifPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			os.Stdout.Write(data)
+			return
+		}
+		sarif, err := utils.ReadSarif(sarifPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, run := range sarif.Runs {
+			for _, r := range run.Results {
+				location := ""
+				if len(r.Locations) > 0 {
+					l := r.Locations[0].PhysicalLocation
+					location = fmt.Sprintf("%s:%d", l.ArtifactLocation.URI, l.Region.StartLine)
+				}
+				fmt.Printf("%s\t%s\t%s\n", r.RuleID, location, r.Message.Text)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resultsCmd)
+	resultsCmd.AddCommand(resultsAddCmd, resultsListCmd, resultsShowCmd)
+	for _, c := range []*cobra.Command{resultsAddCmd, resultsListCmd, resultsShowCmd} {
+		c.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository of the database.")
+		c.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language of the database.")
+		c.Flags().StringVarP(&shaFlag, "sha", "s", "", "The commit SHA, or SHA prefix, of the database.")
+		c.Flags().StringVarP(&dbPathFlag, "db-path", "p", "", "Path to the database.")
+		c.MarkFlagsOneRequired("db-path", "nwo")
+		c.MarkFlagsMutuallyExclusive("db-path", "nwo")
+	}
+	resultsAddCmd.Flags().StringVar(&sarifFlag, "sarif", "", "The SARIF file to store.")
+	resultsAddCmd.Flags().StringVar(&nameFlag, "name", "", "The name of the analysis. Derived from the queries by default.")
+	resultsAddCmd.Flags().StringSliceVarP(&queriesFlag, "queries", "q", nil, "The queries, suites or packs that produced the results.")
+	resultsAddCmd.MarkFlagRequired("sarif")
+	resultsListCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Use json as the output format.")
+	resultsShowCmd.Flags().StringVar(&nameFlag, "name", "", "The name of the analysis to show.")
+	resultsShowCmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Print the SARIF file.")
+	resultsShowCmd.MarkFlagRequired("name")
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v interface{}) {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(jsonBytes))
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"log"
 	"os"
 
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/cli/go-gh/pkg/term"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +15,19 @@ var (
   removeFlag bool
   dbPathFlag string
   jsonFlag bool
+  rootFlag string
+  shaFlag string
+  dryRunFlag bool
+  yesFlag bool
+  resolveRemoteFlag bool
+  formatFlag string
+  jqFlag string
+  ownerFlag string
+  remoteFlag string
+  profileFlag string
+  noProfileFlag bool
+  repoFlag string
+  refFlag string
 )
 var rootCmd = &cobra.Command{
   Use:   "gh-qldb",
@@ -19,9 +35,25 @@ var rootCmd = &cobra.Command{
   Long: `A CodeQL database manager. Download, deploy and create CodeQL databases with ease.`,
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&rootFlag, "root", "", "The QLDB root directory. Defaults to $QLDB_ROOT, the configuration file or ~/codeql-dbs.")
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
 	}
 }
+
+// newStore returns the QLDB store the commands operate on.
+func newStore() *qldb.Store {
+	store, err := qldb.Open(rootFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store.Log = os.Stdout
+	store.Progress = os.Stderr
+	store.ProgressTTY = term.IsTerminal(os.Stderr)
+	return store
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	maxCacheSizeFlag    string
+	maxCacheEntriesFlag int
+)
+
+var unpackCmd = &cobra.Command{
+	Use:   "unpack",
+	Short: "Unpacks a CodeQL database into the QLDB cache",
+	Long: `Unpacks a zipped CodeQL database into the QLDB cache and prints the path of the database directory.
+
+The database is only extracted the first time, later calls reuse it. The least recently used databases are evicted from the cache according to the cacheMaxSize and cacheMaxEntries settings of the configuration file, or the --max-cache-size and --max-cache-entries flags.
+
+eg: gh-qldb unpack --nwo apache/logging-log4j2 --language java`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := newStore()
+		// keep stdout for the path only
+		store.Log = os.Stderr
+		fmt.Println(unpack(store, resolveDatabase(store)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unpackCmd)
+	unpackCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to unpack the database for.")
+	unpackCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language of the database to unpack.")
+	unpackCmd.Flags().StringVarP(&shaFlag, "sha", "s", "", "The commit SHA, or SHA prefix, of the database to unpack.")
+	unpackCmd.Flags().StringVar(&atFlag, "at", "", "Unpack the newest database created at or before this date (YYYY-MM-DD or RFC 3339).")
+	unpackCmd.Flags().StringVarP(&dbPathFlag, "db-path", "p", "", "Path to the database to unpack.")
+	unpackCmd.MarkFlagsOneRequired("db-path", "nwo")
+	unpackCmd.MarkFlagsMutuallyExclusive("db-path", "nwo")
+	unpackCmd.MarkFlagsMutuallyExclusive("sha", "at")
+	for _, c := range []*cobra.Command{unpackCmd, pathCmd} {
+		c.Flags().StringVar(&maxCacheSizeFlag, "max-cache-size", "", "Evict the least recently used databases from the cache above this size, eg: 20GB.")
+		c.Flags().IntVar(&maxCacheEntriesFlag, "max-cache-entries", 0, "Evict the least recently used databases from the cache above this number of databases.")
+	}
+}
+
+// unpack unpacks db into the cache, evicting old entries according to the
+// configuration file or the --max-cache-size and --max-cache-entries flags,
+// and returns the path of the unpacked database.
+func unpack(store *qldb.Store, db *qldb.Database) string {
+	if maxCacheSizeFlag != "" {
+		size, err := utils.ParseSize(maxCacheSizeFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store.Cache.MaxSize = size
+	}
+	if maxCacheEntriesFlag > 0 {
+		store.Cache.MaxEntries = maxCacheEntriesFlag
+	}
+	path, err := store.Unpack(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/GitHubSecurityLab/gh-qldb/pkg/qldb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deepFlag     bool
+	validateFlag bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies the integrity of the CodeQL databases stored in the QLDB structure",
+	Long: `Verifies the integrity of the CodeQL databases stored in the QLDB structure.
+
+Zipped databases are hashed and compared with the SHA-256 and size recorded in their metadata when they were installed or downloaded, and their zip central directory is checked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		verify()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringVarP(&nwoFlag, "nwo", "n", "", "The NWO of the repository to verify the databases for.")
+	verifyCmd.Flags().StringVarP(&languageFlag, "language", "l", "", "The primary language of the databases to verify.")
+	verifyCmd.Flags().BoolVar(&deepFlag, "deep", false, "Decompress every zip entry to check its CRC-32.")
+	verifyCmd.Flags().BoolVar(&validateFlag, "validate", false, "Run `codeql resolve database` on every database.")
+}
+
+func verify() {
+	store := newStore()
+	dbs, err := store.List(qldb.ListOptions{
+		NWO:      nwoFlag,
+		Language: languageFlag,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := qldb.VerifyOptions{ReadEntries: deepFlag, Validate: validateFlag}
+	failed := 0
+	for _, db := range dbs {
+		result := store.Verify(db, opts)
+		if result.Err != nil {
+			failed++
+			fmt.Printf("%s\t%s\t%v\n", result.Status, db.Path, result.Err)
+		} else {
+			fmt.Printf("%s\t%s\n", result.Status, db.Path)
+		}
+	}
+	fmt.Printf("Verified %d database(s), %d failed\n", len(dbs), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
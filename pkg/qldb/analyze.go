@@ -0,0 +1,79 @@
+package qldb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// AnalyzeOptions controls how Analyze runs CodeQL.
+type AnalyzeOptions struct {
+	// Queries are the queries, suites or packs to run. The default suite
+	// for the language of the database is run when empty.
+	Queries []string
+	// Name identifies the analysis in the SARIF file name. It is derived
+	// from Queries when empty.
+	Name string
+	// Args are additional arguments for `codeql database analyze`.
+	Args []string
+}
+
+// AnalysisName returns the name the SARIF file of an analysis running
+// queries is stored under.
+func AnalysisName(queries []string) string {
+	if len(queries) == 0 {
+		return "default"
+	}
+	return sanitizeName(strings.Join(queries, "+"))
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._+-]+`)
+
+func sanitizeName(name string) string {
+	return strings.Trim(unsafeNameChars.ReplaceAllString(name, "-"), "-.")
+}
+
+// Analyze runs `codeql database analyze` on db, unpacking it if needed, and
+// stores the SARIF results in the results directory of the database. It
+// returns the recorded analysis.
+func (s *Store) Analyze(db *Database, opts AnalyzeOptions) (*utils.AnalysisMetadata, error) {
+	dbDir, err := s.Unpack(db)
+	if err != nil {
+		return nil, err
+	}
+	name := opts.Name
+	if name == "" {
+		name = AnalysisName(opts.Queries)
+	}
+	sarifPath := SarifPath(db.Path, name)
+	if err := os.MkdirAll(filepath.Dir(sarifPath), 0755); err != nil {
+		return nil, err
+	}
+
+	args := []string{"database", "analyze", "--format=sarif-latest", "--output=" + sarifPath}
+	args = append(args, opts.Args...)
+	args = append(args, "--", dbDir)
+	args = append(args, opts.Queries...)
+	s.logf("Analyzing '%s' with '%s'\n", db.Path, name)
+	cmd := exec.Command("codeql", args...)
+	cmd.Env = os.Environ()
+	cmd.Stdout = s.logOut()
+	cmd.Stderr = s.logOut()
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("codeql database analyze failed: %w", err)
+	}
+	return s.AddResult(db, sarifPath, name, opts.Queries)
+}
+
+func (s *Store) logOut() io.Writer {
+	if s.Log == nil {
+		return io.Discard
+	}
+	return s.Log
+}
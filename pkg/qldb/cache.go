@@ -0,0 +1,210 @@
+package qldb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+const (
+	// DefaultCacheName is the name of the directory, in the QLDB root, where
+	// databases are unpacked when no cache directory is configured.
+	DefaultCacheName = ".cache"
+	// unpackedMarker is created in every complete cache entry. Its
+	// modification time records when the entry was last used.
+	unpackedMarker = ".qldb-unpacked"
+	// unpackPattern names the temporary directories databases are unzipped
+	// to before being moved into the cache.
+	unpackPattern = ".unpack-*"
+	// staleUnpackAge is the age after which a temporary unpack directory is
+	// considered left behind by an interrupted unpack.
+	staleUnpackAge = 24 * time.Hour
+)
+
+// CacheOptions is the eviction policy of the unpacked database cache. Zero
+// values disable the corresponding limit.
+type CacheOptions struct {
+	// MaxSize is the total size in bytes the cache may take.
+	MaxSize int64
+	// MaxEntries is the number of unpacked databases the cache may hold.
+	MaxEntries int
+}
+
+// CachePath returns the directory where databases are unpacked.
+func (s *Store) CachePath() string {
+	if s.CacheDir != "" {
+		return s.CacheDir
+	}
+	return filepath.Join(s.Root, DefaultCacheName)
+}
+
+// unpackedPath returns the cache entry for the zipped database at dbPath.
+func (s *Store) unpackedPath(dbPath string) string {
+	return filepath.Join(s.CachePath(), s.Host, filepath.FromSlash(trimDBExt(s.relPath(dbPath))))
+}
+
+// Unpack returns the path of an unpacked copy of db, extracting it into the
+// cache the first time. The least recently used databases are then evicted
+// from the cache according to s.Cache. Database directories are returned as
+// they are.
+func (s *Store) Unpack(db *Database) (string, error) {
+	fi, err := os.Stat(db.Path)
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		return databaseRoot(db.Path), nil
+	}
+	path, err := s.unpackZip(db, fi)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.EvictCache(s.Cache); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// unpackZip returns the cache entry of the zipped database db, extracting it
+// when it is missing or older than the zip file described by fi.
+func (s *Store) unpackZip(db *Database, fi os.FileInfo) (string, error) {
+	if rel := s.relPath(db.Path); rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s is not stored in %s", db.Path, s.BasePath())
+	}
+
+	dir := s.unpackedPath(db.Path)
+	marker := filepath.Join(dir, unpackedMarker)
+	if mfi, err := os.Stat(marker); err == nil && !mfi.ModTime().Before(fi.ModTime()) {
+		now := time.Now()
+		if err := os.Chtimes(marker, now, now); err != nil {
+			return "", err
+		}
+		return databaseRoot(dir), nil
+	}
+
+	// unzip to a temporary directory next to the entry and move it in place
+	// once complete, so an interrupted unpack is never reused
+	s.logf("Unpacking '%s'\n", db.Path)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	tmpdir, err := os.MkdirTemp(filepath.Dir(dir), unpackPattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpdir)
+	if _, err := utils.Unzip(db.Path, tmpdir); err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpdir, dir); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		return "", err
+	}
+	return databaseRoot(dir), nil
+}
+
+// databaseRoot returns the database directory in dir, which is dir itself
+// or the single directory it contains.
+func databaseRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return dir
+	}
+	var dirs []os.DirEntry
+	for _, e := range entries {
+		if e.Name() == unpackedMarker {
+			continue
+		}
+		dirs = append(dirs, e)
+	}
+	if len(dirs) == 1 && dirs[0].IsDir() {
+		return filepath.Join(dir, dirs[0].Name())
+	}
+	return dir
+}
+
+type cacheEntry struct {
+	path     string
+	lastUsed time.Time
+	size     int64
+}
+
+// EvictCache removes the least recently used unpacked databases until the
+// cache fits opts. The most recently used database is always kept. The
+// temporary directories of unpacks interrupted more than a day ago are
+// removed too.
+func (s *Store) EvictCache(opts CacheOptions) ([]string, error) {
+	var entries []cacheEntry
+	err := filepath.Walk(s.CachePath(), func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if matched, _ := filepath.Match(unpackPattern, info.Name()); !matched {
+				return nil
+			}
+			if time.Since(info.ModTime()) > staleUnpackAge {
+				s.logf("Removing interrupted unpack '%s'\n", path)
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+			}
+			return filepath.SkipDir
+		}
+		if info.Name() != unpackedMarker {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		size, err := diskUsage(dir)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, cacheEntry{dir, info.ModTime(), size})
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.After(entries[j].lastUsed)
+	})
+	var total int64
+	var evicted []string
+	for i, e := range entries {
+		total += e.size
+		if i == 0 {
+			continue
+		}
+		if (opts.MaxSize > 0 && total > opts.MaxSize) || (opts.MaxEntries > 0 && i >= opts.MaxEntries) {
+			s.logf("Evicting '%s' from the cache\n", e.path)
+			if err := os.RemoveAll(e.path); err != nil {
+				return evicted, err
+			}
+			total -= e.size
+			evicted = append(evicted, e.path)
+		}
+	}
+	return evicted, nil
+}
+
+// removeUnpacked deletes the cache entry of the database at dbPath.
+func (s *Store) removeUnpacked(dbPath string) error {
+	dir := s.unpackedPath(dbPath)
+	if !strings.HasPrefix(dir, s.CachePath()+string(filepath.Separator)) {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,79 @@
+package qldb
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUnpackEvictsCache(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	s.Cache = CacheOptions{MaxEntries: 2}
+	var paths []string
+	for i, sha := range []string{"00000001", "00000002", "00000003"} {
+		db := &Database{Path: s.DatabasePath("foo/bar", "java", sha)}
+		writeZip(t, db.Path, "codeql-database.yml")
+		path, err := s.Unpack(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// order the entries by use without relying on the clock resolution
+		used := time.Now().Add(time.Duration(i-10) * time.Minute)
+		if err := os.Chtimes(filepath.Join(path, unpackedMarker), used, used); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	for i, path := range paths {
+		if want := i > 0; exists(path) != want {
+			t.Errorf("cache entry %s exists = %v, want %v", path, exists(path), want)
+		}
+	}
+}
+
+func TestEvictCacheRemovesInterruptedUnpacks(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	dir := filepath.Join(s.CachePath(), s.Host, "foo", "bar")
+	stale := filepath.Join(dir, ".unpack-1")
+	running := filepath.Join(dir, ".unpack-2")
+	for _, path := range []string{stale, running} {
+		createFile(t, filepath.Join(path, "codeql-database.yml"))
+	}
+	old := time.Now().Add(-2 * staleUnpackAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.EvictCache(CacheOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if exists(stale) {
+		t.Errorf("%s was not removed", stale)
+	}
+	if !exists(running) {
+		t.Errorf("%s was removed", running)
+	}
+}
+
+func writeZip(t *testing.T, path string, names ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, name := range names {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+package qldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/cli/go-gh/pkg/auth"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultRootName is the name of the QLDB directory created in the
+	// user's home directory when no root is configured.
+	DefaultRootName = "codeql-dbs"
+	// RootEnv is the environment variable that overrides the QLDB root.
+	RootEnv = "QLDB_ROOT"
+	// ConfigEnv is the environment variable that overrides the location of
+	// the configuration file.
+	ConfigEnv = "QLDB_CONFIG"
+)
+
+// Config is the contents of the QLDB configuration file.
+type Config struct {
+	// Root is the directory holding the QLDB structure.
+	Root string `yaml:"root"`
+	// Cache is the directory where databases are unpacked.
+	Cache string `yaml:"cache"`
+	// CacheMaxSize is the total size the unpacked databases may take, eg:
+	// 20GB.
+	CacheMaxSize string `yaml:"cacheMaxSize"`
+	// CacheMaxEntries is the number of unpacked databases to keep.
+	CacheMaxEntries int `yaml:"cacheMaxEntries"`
+	// Profiles are the build profiles used by create, by name.
+	Profiles map[string]*BuildProfile `yaml:"profiles"`
+}
+
+// CacheOptions returns the cache eviction policy of the configuration.
+func (c *Config) CacheOptions() (CacheOptions, error) {
+	opts := CacheOptions{MaxEntries: c.CacheMaxEntries}
+	if c.CacheMaxSize != "" {
+		size, err := utils.ParseSize(c.CacheMaxSize)
+		if err != nil {
+			return opts, err
+		}
+		opts.MaxSize = size
+	}
+	return opts, nil
+}
+
+// ConfigPath returns the location of the QLDB configuration file.
+func ConfigPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-qldb", "config.yml"), nil
+}
+
+// LoadConfig reads the QLDB configuration file. A missing file results in an
+// empty configuration.
+func LoadConfig() (*Config, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	for name, profile := range config.Profiles {
+		if profile == nil {
+			return nil, fmt.Errorf("invalid configuration file %s: empty profile %s", path, name)
+		}
+		profile.Name = name
+	}
+	return &config, nil
+}
+
+// ResolveRoot returns the QLDB root directory. root takes precedence when
+// not empty, followed by the QLDB_ROOT environment variable, the
+// configuration file and finally ~/codeql-dbs.
+func ResolveRoot(root string) (string, error) {
+	if root == "" {
+		root = os.Getenv(RootEnv)
+	}
+	if root == "" {
+		config, err := LoadConfig()
+		if err != nil {
+			return "", err
+		}
+		root = config.Root
+	}
+	if root == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, DefaultRootName), nil
+	}
+	root, err := expandHome(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(root)
+}
+
+// DefaultHost returns the GitHub host gh is configured to use.
+func DefaultHost() string {
+	host, _ := auth.DefaultHost()
+	return host
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !(len(path) > 1 && path[:2] == "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
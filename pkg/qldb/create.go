@@ -0,0 +1,169 @@
+package qldb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// createLogTail is the amount of CodeQL output saved when creating a database
+// fails.
+const createLogTail = 64 * 1024
+
+// CreateOptions controls how Create runs CodeQL.
+type CreateOptions struct {
+	// Args are the arguments for `codeql database create`, such as the
+	// source root and the language.
+	Args []string
+	// CommitSha is the commit the database is created from. It is used when
+	// CodeQL does not record one.
+	CommitSha string
+	// Profile is the build profile applied to Args, if any.
+	Profile *BuildProfile
+}
+
+// CreateResult is the outcome of installing a database created by Create.
+type CreateResult struct {
+	// Language is the language of the database, the name of its directory
+	// in a database cluster.
+	Language string
+	// Database is the installed database, nil if installing it failed.
+	Database *Database
+	Err      error
+}
+
+// Create runs `codeql database create` in a temporary directory and installs
+// the database in the QLDB structure under nwo. When CodeQL creates a
+// database cluster, with --db-cluster, every database in the cluster is
+// installed. The CodeQL output is written to Log. When CodeQL fails, the end
+// of its output is saved in create-<language>.log in the directory of nwo
+// until the next successful run. The returned error is only set when no
+// database could be created, the outcome of installing each database is
+// reported in its CreateResult.
+func (s *Store) Create(nwo string, opts CreateOptions) ([]*CreateResult, error) {
+	tmpdir, err := os.MkdirTemp("", "qldb-create-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+	dbDir := filepath.Join(tmpdir, "db")
+
+	build := &utils.BuildMetadata{Args: opts.Args}
+	if opts.Profile != nil {
+		build.Profile = opts.Profile.Name
+		build.Args = opts.Profile.Apply(opts.Args)
+	}
+	args := []string{"database", "create"}
+	args = append(args, build.Args...)
+	args = append(args, "--", dbDir)
+	s.logf("Creating database for '%s'. CodeQL args: '%v'\n", nwo, build.Args)
+	tail := &tailBuffer{max: createLogTail}
+	out := io.MultiWriter(s.logOut(), tail)
+	cmd := exec.Command("codeql", args...)
+	cmd.Env = os.Environ()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	logPath := filepath.Join(s.Path(nwo), "create-"+createLogName(build.Args)+".log")
+	if err := cmd.Run(); err != nil {
+		if werr := os.MkdirAll(filepath.Dir(logPath), 0755); werr == nil {
+			if werr := os.WriteFile(logPath, tail.Bytes(), 0644); werr == nil {
+				return nil, fmt.Errorf("codeql database create failed, see %s: %w", logPath, err)
+			}
+		}
+		return nil, fmt.Errorf("codeql database create failed: %w", err)
+	}
+	if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	dbDirs, err := clusterDatabases(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	var results []*CreateResult
+	for _, dir := range dbDirs {
+		result := &CreateResult{Language: filepath.Base(dir)}
+		result.Database, result.Err = s.Install(nwo, dir, InstallOptions{CommitSha: opts.CommitSha, Build: build})
+		if result.Database != nil {
+			result.Language = result.Database.Language
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// clusterDatabases returns the database directories created by CodeQL in
+// dir: dir itself, or the language databases of a database cluster.
+func clusterDatabases(dir string) ([]string, error) {
+	if exists(filepath.Join(dir, "codeql-database.yml")) {
+		return []string{dir}, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && exists(filepath.Join(dir, e.Name(), "codeql-database.yml")) {
+			dirs = append(dirs, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no database found in %s", dir)
+	}
+	return dirs, nil
+}
+
+// createLogName returns the languages passed to CodeQL in args, joined with
+// "+", or "database" if there are none.
+func createLogName(args []string) string {
+	languages := languageArgs(args)
+	if len(languages) == 0 {
+		return "database"
+	}
+	return sanitizeName(strings.Join(languages, "+"))
+}
+
+// languageArgs returns the languages passed to CodeQL in args.
+func languageArgs(args []string) []string {
+	var languages []string
+	for _, o := range parseOptions(args) {
+		if o.name == "--language" {
+			for _, value := range o.values {
+				languages = append(languages, strings.Split(value, ",")...)
+			}
+		}
+	}
+	return languages
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+// Bytes returns the kept output starting at a line boundary.
+func (t *tailBuffer) Bytes() []byte {
+	if len(t.buf) < t.max {
+		return t.buf
+	}
+	if i := bytes.IndexByte(t.buf, '\n'); i >= 0 {
+		return t.buf[i+1:]
+	}
+	return t.buf
+}
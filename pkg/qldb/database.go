@@ -0,0 +1,283 @@
+package qldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// Database is a CodeQL database stored in the QLDB structure.
+type Database struct {
+	// NWO is the owner/repo the database belongs to.
+	NWO string
+	// Language is the primary language of the database.
+	Language string
+	// ShortSha is the abbreviated commit SHA encoded in the database name.
+	ShortSha string
+	// CommitSha is the full commit SHA, when known.
+	CommitSha string
+	// CommittedDate is the date of the commit, when known.
+	CommittedDate string
+	// Path is the location of the zip file or database directory.
+	Path string
+	// Size is the size of the database in bytes, when known.
+	Size int64
+	// Metadata is the database metadata, when known.
+	Metadata *utils.DatabaseMetadata
+}
+
+// InfoOptions controls how Info gathers information about a database.
+type InfoOptions struct {
+	// ResolveRemote looks up the commit date using the GitHub API when it
+	// is not known yet.
+	ResolveRemote bool
+	// Language restricts InfoForNWO to the databases for this language.
+	Language string
+}
+
+// ListOptions restricts the databases returned by List.
+type ListOptions struct {
+	// NWO keeps the databases for this repository. It can be a glob
+	// pattern, such as apache/*. Matching is case insensitive.
+	NWO string
+	// Owner keeps the databases of the repositories owned by this user or
+	// organization.
+	Owner string
+	// Language keeps the databases for this language.
+	Language string
+	// Languages keeps the databases for any of these languages, in addition
+	// to Language.
+	Languages []string
+	// Sha keeps the databases whose commit SHA starts with this prefix.
+	Sha string
+}
+
+// List returns the databases stored in the QLDB structure. It is answered
+// from the local index, which is built on first use.
+func (s *Store) List(opts ListOptions) ([]*Database, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []*Database
+	for _, db := range idx.databases(s) {
+		if !opts.matchLanguage(db.Language) {
+			continue
+		}
+		if opts.NWO != "" && !matchNWO(db.NWO, opts.NWO) {
+			continue
+		}
+		if owner, _, _ := strings.Cut(db.NWO, "/"); opts.Owner != "" && !strings.EqualFold(owner, opts.Owner) {
+			continue
+		}
+		if opts.Sha != "" && !matchSha(db.ShortSha, opts.Sha) {
+			continue
+		}
+		filtered = append(filtered, db)
+	}
+	return filtered, nil
+}
+
+func (opts ListOptions) matchLanguage(language string) bool {
+	if opts.Language == "" && len(opts.Languages) == 0 {
+		return true
+	}
+	return language == opts.Language || contains(opts.Languages, language)
+}
+
+// matchNWO reports whether nwo matches pattern, which is either a NWO or a
+// glob pattern.
+func matchNWO(nwo string, pattern string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(nwo))
+	return err == nil && matched
+}
+
+// scan walks the QLDB structure and returns the databases found in it along
+// with their metadata files, when present, and the paths of the entries that
+// are not named like databases.
+func (s *Store) scan() ([]*Database, []string, error) {
+	var results []*Database
+	var unrecognized []string
+	basePath := s.BasePath()
+	userEntries, err := os.ReadDir(basePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// nothing has been stored for this host yet
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	for _, userEntry := range userEntries {
+		if !userEntry.IsDir() {
+			continue
+		}
+		user := userEntry.Name()
+		repoEntries, err := os.ReadDir(filepath.Join(basePath, user))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, repoEntry := range repoEntries {
+			if !repoEntry.IsDir() {
+				continue
+			}
+			nwo := user + "/" + repoEntry.Name()
+			dbs, skipped, err := s.listNWO(nwo)
+			if err != nil {
+				return nil, nil, err
+			}
+			results = append(results, dbs...)
+			unrecognized = append(unrecognized, skipped...)
+		}
+	}
+	for _, db := range results {
+		if metadata, err := ReadMetadata(db.Path); err == nil {
+			db.Metadata = metadata
+			db.CommitSha = metadata.CommitSha()
+		}
+	}
+	return results, unrecognized, nil
+}
+
+// listNWO returns the databases stored for nwo without resolving any
+// additional information, and the paths of the entries that are not named
+// like databases.
+func (s *Store) listNWO(nwo string) ([]*Database, []string, error) {
+	dir := s.Path(nwo)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var results []*Database
+	var unrecognized []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) != ".zip" {
+			continue
+		}
+		// skip in-progress downloads and installs
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		// skip analysis results
+		if filepath.Ext(e.Name()) == ".results" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		language, sha, err := ParseName(e.Name())
+		if err != nil {
+			unrecognized = append(unrecognized, path)
+			continue
+		}
+		results = append(results, &Database{
+			NWO:      nwo,
+			Language: language,
+			ShortSha: sha,
+			Path:     path,
+		})
+	}
+	return results, unrecognized, nil
+}
+
+// Info returns the database at path. It is answered from the local index,
+// the metadata file next to the database or the codeql-database.yml file in
+// the database itself, without contacting GitHub unless ResolveRemote is set.
+func (s *Store) Info(path string, opts InfoOptions) (*Database, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	db := idx.lookup(s, path)
+	if db == nil {
+		if db, err = s.inspect(path); err != nil {
+			return nil, err
+		}
+	}
+	if db.Metadata == nil {
+		if db.Metadata, err = ReadMetadata(db.Path); err != nil {
+			if db.Metadata, err = utils.ReadDatabaseMetadata(db.Path); err != nil {
+				s.logf("Cannot read metadata for '%s': %v\n", db.Path, err)
+			}
+		}
+	}
+	if db.CommitSha == "" && db.Metadata != nil {
+		db.CommitSha = db.Metadata.CommitSha()
+	}
+	if db.Size, err = diskUsage(db.Path); err != nil {
+		return nil, err
+	}
+
+	if opts.ResolveRemote && db.CommittedDate == "" {
+		sha := db.CommitSha
+		if sha == "" {
+			sha = db.ShortSha
+		}
+		commitSha, committedDate, err := utils.GetCommitInfo2(s.Host, db.NWO, sha)
+		if err != nil {
+			return nil, err
+		}
+		if db.CommitSha == "" {
+			db.CommitSha = commitSha
+		}
+		db.CommittedDate = committedDate
+		if err := s.updateIndex(func(idx *index) { idx.add(s, db) }); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// inspect returns the database at path from its location in the QLDB
+// structure.
+func (s *Store) inspect(path string) (*Database, error) {
+	name := filepath.Base(path)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() && filepath.Ext(name) != ".zip" {
+		return nil, fmt.Errorf("%w: %s", ErrUnrecognized, path)
+	}
+	id, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{
+		NWO:      id.NWO,
+		Language: id.Language,
+		ShortSha: id.ShortSha,
+		Path:     path,
+	}, nil
+}
+
+// InfoForNWO returns the databases stored for nwo, which can be a glob
+// pattern as in ListOptions, and for opts.Language when it is set. Only those
+// databases are looked up on GitHub with opts.ResolveRemote.
+func (s *Store) InfoForNWO(nwo string, opts InfoOptions) ([]*Database, error) {
+	dbs, err := s.List(ListOptions{NWO: nwo, Language: opts.Language})
+	if err != nil {
+		return nil, err
+	}
+	var results []*Database
+	for _, db := range dbs {
+		info, err := s.Info(db.Path, opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, info)
+	}
+	return results, nil
+}
+
+// matchSha reports whether the short SHA of a database and the given SHA or
+// SHA prefix refer to the same commit.
+func matchSha(short string, sha string) bool {
+	if short == "" {
+		return false
+	}
+	short, sha = strings.ToLower(short), strings.ToLower(sha)
+	return strings.HasPrefix(short, sha) || strings.HasPrefix(sha, short)
+}
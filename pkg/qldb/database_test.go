@@ -0,0 +1,142 @@
+package qldb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+func TestList(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	for _, path := range []string{
+		"foo/bar/java-0123abcd.zip",
+		"foo/bar/go-89abcdef.zip",
+		"foo/bar-extra/java-0123abcd.zip",
+		"foo/baz/javascript-fedcba98.zip",
+		"qux/bar/java-01234567.zip",
+	} {
+		createFile(t, filepath.Join(s.BasePath(), path))
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"all", ListOptions{}, []string{"foo/bar-extra/java-0123abcd.zip", "foo/bar/go-89abcdef.zip", "foo/bar/java-0123abcd.zip", "foo/baz/javascript-fedcba98.zip", "qux/bar/java-01234567.zip"}},
+		// foo/bar used to match foo/bar-extra as well
+		{"exact nwo", ListOptions{NWO: "foo/bar"}, []string{"foo/bar/go-89abcdef.zip", "foo/bar/java-0123abcd.zip"}},
+		{"nwo case", ListOptions{NWO: "Foo/Bar-Extra"}, []string{"foo/bar-extra/java-0123abcd.zip"}},
+		{"nwo prefix", ListOptions{NWO: "foo/ba"}, nil},
+		{"glob", ListOptions{NWO: "foo/bar*"}, []string{"foo/bar-extra/java-0123abcd.zip", "foo/bar/go-89abcdef.zip", "foo/bar/java-0123abcd.zip"}},
+		{"glob owner", ListOptions{NWO: "*/bar"}, []string{"foo/bar/go-89abcdef.zip", "foo/bar/java-0123abcd.zip", "qux/bar/java-01234567.zip"}},
+		{"owner", ListOptions{Owner: "QUX"}, []string{"qux/bar/java-01234567.zip"}},
+		{"owner is not a prefix", ListOptions{Owner: "fo"}, nil},
+		{"language", ListOptions{Language: "java"}, []string{"foo/bar-extra/java-0123abcd.zip", "foo/bar/java-0123abcd.zip", "qux/bar/java-01234567.zip"}},
+		{"languages", ListOptions{Language: "go", Languages: []string{"javascript"}}, []string{"foo/bar/go-89abcdef.zip", "foo/baz/javascript-fedcba98.zip"}},
+		{"sha prefix", ListOptions{Sha: "0123"}, []string{"foo/bar-extra/java-0123abcd.zip", "foo/bar/java-0123abcd.zip", "qux/bar/java-01234567.zip"}},
+		{"sha prefix case", ListOptions{Sha: "0123ABC"}, []string{"foo/bar-extra/java-0123abcd.zip", "foo/bar/java-0123abcd.zip"}},
+		{"full sha", ListOptions{Sha: "89abcdef0123456789abcdef0123456789abcdef"}, []string{"foo/bar/go-89abcdef.zip"}},
+		{"sha mismatch", ListOptions{Sha: "0124"}, nil},
+		{"combined", ListOptions{NWO: "foo/*", Language: "java", Sha: "0123abcd"}, []string{"foo/bar-extra/java-0123abcd.zip", "foo/bar/java-0123abcd.zip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbs, err := s.List(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, db := range dbs {
+				got = append(got, s.relPath(db.Path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List(%+v) = %v, want %v", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	now := time.Now()
+	day := 24 * time.Hour
+	for path, age := range map[string]time.Duration{
+		"foo/bar/java-0123abcd.zip":   1 * day,
+		"foo/bar/java-0123ffff.zip":   3 * day,
+		"foo/bar/java-89abcdef.zip":   5 * day,
+		"foo/multi/java-0123abcd.zip": 1 * day,
+		"foo/multi/go-0123abcd.zip":   2 * day,
+	} {
+		path = filepath.Join(s.BasePath(), path)
+		createFile(t, path)
+		data, err := json.Marshal(&utils.DatabaseMetadata{
+			CreationMetadata: &utils.CreationMetadata{CreationTime: now.Add(-age)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(MetadataPath(path), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    ResolveOptions
+		want    string
+		wantErr error
+	}{
+		{"newest", ResolveOptions{NWO: "foo/bar", Language: "java"}, "foo/bar/java-0123abcd.zip", nil},
+		{"single language", ResolveOptions{NWO: "foo/bar"}, "foo/bar/java-0123abcd.zip", nil},
+		{"nwo case", ResolveOptions{NWO: "Foo/Bar"}, "foo/bar/java-0123abcd.zip", nil},
+		{"at", ResolveOptions{NWO: "foo/bar", At: now.Add(-2 * day)}, "foo/bar/java-0123ffff.zip", nil},
+		{"at older", ResolveOptions{NWO: "foo/bar", At: now.Add(-4 * day)}, "foo/bar/java-89abcdef.zip", nil},
+		{"at before all", ResolveOptions{NWO: "foo/bar", At: now.Add(-10 * day)}, "", ErrNotFound},
+		{"sha", ResolveOptions{NWO: "foo/bar", Sha: "89abcdef"}, "foo/bar/java-89abcdef.zip", nil},
+		{"sha prefix", ResolveOptions{NWO: "foo/bar", Sha: "0123f"}, "foo/bar/java-0123ffff.zip", nil},
+		{"ambiguous sha prefix", ResolveOptions{NWO: "foo/bar", Sha: "0123"}, "", ErrAmbiguous},
+		{"several languages", ResolveOptions{NWO: "foo/multi"}, "", ErrAmbiguous},
+		{"language", ResolveOptions{NWO: "foo/multi", Language: "go"}, "foo/multi/go-0123abcd.zip", nil},
+		{"missing nwo", ResolveOptions{NWO: "foo/missing"}, "", ErrNotFound},
+		{"missing language", ResolveOptions{NWO: "foo/bar", Language: "go"}, "", ErrNotFound},
+		{"missing sha", ResolveOptions{NWO: "foo/bar", Sha: "fedcba98"}, "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := s.Resolve(tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Resolve(%+v) returned %v, want %v", tt.opts, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%+v) returned %v", tt.opts, err)
+			}
+			if got := s.relPath(db.Path); got != tt.want {
+				t.Errorf("Resolve(%+v) = %s, want %s", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfoForNWOLanguage(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	for _, path := range []string{"foo/bar/java-0123abcd.zip", "foo/bar/go-89abcdef.zip"} {
+		createFile(t, filepath.Join(s.BasePath(), path))
+	}
+	dbs, err := s.InfoForNWO("foo/bar", InfoOptions{Language: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dbs) != 1 || dbs[0].Language != "go" {
+		t.Errorf("InfoForNWO returned %d databases, want the go database", len(dbs))
+	}
+}
@@ -0,0 +1,423 @@
+package qldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+	"github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/api"
+)
+
+// RemoteDatabase is a database available in GitHub Code Scanning.
+type RemoteDatabase struct {
+	Language string `json:"language"`
+	// CommitOid is the commit the database was created from. It is not
+	// reported by older GitHub Enterprise Server versions.
+	CommitOid string    `json:"commit_oid"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RemoteDatabases returns the databases GitHub Code Scanning has for nwo.
+func (s *Store) RemoteDatabases(nwo string) ([]RemoteDatabase, error) {
+	restClient, err := gh.RESTClient(&api.ClientOptions{Host: s.Host})
+	if err != nil {
+		return nil, err
+	}
+	var response []RemoteDatabase
+	err = restClient.Get(fmt.Sprintf("repos/%s/code-scanning/codeql/databases", nwo), &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// DownloadStatus is the outcome of a download.
+type DownloadStatus string
+
+const (
+	Downloaded DownloadStatus = "downloaded"
+	Skipped    DownloadStatus = "skipped"
+	Failed     DownloadStatus = "failed"
+)
+
+// DownloadResult is the outcome of downloading the database for a repository
+// and language.
+type DownloadResult struct {
+	NWO      string
+	Language string
+	Status   DownloadStatus
+	// Database is the stored database, unless the download failed.
+	Database *Database
+	Err      error
+}
+
+// DownloadAll downloads the databases for each of nwos, running up to jobs
+// downloads at the same time. Only the given languages are downloaded, or
+// every available language when languages is empty. Databases that are
+// already stored are skipped.
+func (s *Store) DownloadAll(nwos []string, languages []string, jobs int) []*DownloadResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	type task struct {
+		nwo    string
+		remote RemoteDatabase
+	}
+	var (
+		mu      sync.Mutex
+		results []*DownloadResult
+		tasks   []task
+	)
+	addResult := func(r *DownloadResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, r)
+	}
+
+	// find out which databases are available
+	forEach(len(nwos), jobs, func(i int) {
+		nwo := nwos[i]
+		remotes, err := s.RemoteDatabases(nwo)
+		if err != nil {
+			addResult(&DownloadResult{NWO: nwo, Language: strings.Join(languages, ","), Status: Failed, Err: err})
+			return
+		}
+		found := map[string]bool{}
+		for _, remote := range remotes {
+			if len(languages) > 0 && !contains(languages, remote.Language) {
+				continue
+			}
+			found[remote.Language] = true
+			mu.Lock()
+			tasks = append(tasks, task{nwo, remote})
+			mu.Unlock()
+		}
+		for _, language := range languages {
+			if !found[language] {
+				addResult(&DownloadResult{NWO: nwo, Language: language, Status: Failed, Err: ErrNotFound})
+			}
+		}
+	})
+
+	// progress bars of concurrent downloads would overwrite each other
+	tty := s.ProgressTTY && (len(tasks) == 1 || jobs == 1)
+	forEach(len(tasks), jobs, func(i int) {
+		t := tasks[i]
+		r := &DownloadResult{NWO: t.nwo, Language: t.remote.Language, Status: Downloaded}
+		r.Database, r.Err = s.download(t.nwo, t.remote, tty)
+		if errors.Is(r.Err, errExists) {
+			r.Status, r.Err = Skipped, nil
+		} else if r.Err != nil {
+			r.Status = Failed
+		}
+		addResult(r)
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].NWO != results[j].NWO {
+			return results[i].NWO < results[j].NWO
+		}
+		return results[i].Language < results[j].Language
+	})
+	return results
+}
+
+// forEach calls fn for 0 <= i < n using up to jobs goroutines.
+func forEach(n int, jobs int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// errExists is returned by download when the database is already stored.
+var errExists = errors.New("database already exists")
+
+// downloadAttempts is the number of times a download is attempted before
+// giving up. Every attempt resumes where the previous one stopped.
+const downloadAttempts = 3
+
+// Download fetches the GitHub Code Scanning database for nwo and language and
+// stores it in the QLDB structure.
+//
+// The database is streamed to a .part file in the repository directory. When
+// the transfer fails the .part file is kept, along with the ETag or
+// Last-Modified date of the database it holds, and the next attempt, or the
+// next call to Download, resumes it using a HTTP If-Range request. The .part
+// file is started over when the database changed in the meantime.
+func (s *Store) Download(nwo string, language string) (*Database, error) {
+	db, err := s.download(nwo, RemoteDatabase{Language: language}, s.ProgressTTY)
+	if errors.Is(err, errExists) {
+		return db, nil
+	}
+	return db, err
+}
+
+// download stores the remote database for nwo. It returns errExists along
+// with the stored database when it is already present.
+func (s *Store) download(nwo string, remote RemoteDatabase, tty bool) (*Database, error) {
+	language := remote.Language
+	if remote.CommitOid != "" {
+		zipPath := s.DatabasePath(nwo, language, remote.CommitOid)
+		if exists(zipPath) {
+			s.logf("DB %s already exists\n", zipPath)
+			return s.stored(nwo, zipPath), errExists
+		}
+	}
+	s.logf("Downloading '%s' DB for '%s'\n", language, nwo)
+	dir := s.Path(nwo)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	// the commit is not reported by older GitHub Enterprise Server versions
+	partPath := filepath.Join(dir, fmt.Sprintf(".%s.zip.part", language))
+	if remote.CommitOid != "" {
+		partPath = filepath.Join(dir, fmt.Sprintf(".%s-%s.zip.part", language, shortSha(remote.CommitOid)))
+	}
+
+	var err error
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		if err = s.fetch(nwo, language, partPath, tty); err == nil {
+			break
+		}
+		s.logf("Download of '%s' DB for '%s' interrupted (attempt %d/%d): %v\n", language, nwo, attempt, downloadAttempts, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// get the commit this DB was created from
+	s.logf("Extracting database information ... ")
+	metadata, err := utils.ExtractDBInfo(partPath)
+	if err == nil && remote.CommitOid != "" && !strings.EqualFold(metadata.CommitSha(), remote.CommitOid) {
+		err = fmt.Errorf("downloaded database is for commit %s instead of %s", metadata.CommitSha(), remote.CommitOid)
+	}
+	if err != nil {
+		// the downloaded file is not usable, start over next time
+		removePart(partPath)
+		return nil, err
+	}
+	metadata.Provenance = nwo
+	s.logf("\nCommit SHA: %s\n", metadata.CommitSha())
+	s.logf("Short Commit SHA: %s\n", metadata.ShortCommitSha())
+	s.logf("Primary language: %s\n", metadata.PrimaryLanguage)
+
+	zipPath := s.DatabasePath(nwo, metadata.PrimaryLanguage, metadata.CommitSha())
+
+	// move the DB in place if it does not exist
+	if exists(zipPath) {
+		s.logf("Aborting, DB %s already exists\n", zipPath)
+		removePart(partPath)
+		return s.stored(nwo, zipPath), errExists
+	}
+	s.logf("Writing DB to %s\n", zipPath)
+	if err := os.Rename(partPath, zipPath); err != nil {
+		return nil, err
+	}
+	os.Remove(validatorPath(partPath))
+
+	metadata, err = s.writeMetadata(zipPath, metadata, true)
+	if err != nil {
+		return nil, err
+	}
+	db := newDatabase(nwo, zipPath, metadata)
+	if err := s.updateIndex(func(idx *index) { idx.add(s, db) }); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// fetch downloads the database for nwo and language into partPath, resuming
+// from the current size of partPath when it holds the same version of the
+// database.
+func (s *Store) fetch(nwo string, language string, partPath string, tty bool) error {
+	path := fmt.Sprintf("repos/%s/code-scanning/codeql/databases/%s", nwo, language)
+	get := func(headers map[string]string) (*http.Response, error) {
+		headers["Accept"] = "application/zip"
+		restClient, err := gh.RESTClient(&api.ClientOptions{Host: s.Host, Headers: headers})
+		if err != nil {
+			return nil, err
+		}
+		return restClient.Request("GET", path, nil)
+	}
+	return s.fetchPart(get, path, fmt.Sprintf("%s/%s", nwo, language), partPath, tty)
+}
+
+// fetchPart downloads path into partPath using get, which sends a GET request
+// with the given additional headers. The progress is reported as label.
+func (s *Store) fetchPart(get func(headers map[string]string) (*http.Response, error), path string, label string, partPath string, tty bool) error {
+	var offset int64
+	validator, verr := readValidator(partPath)
+	if fi, err := os.Stat(partPath); err == nil {
+		offset = fi.Size()
+	}
+	if offset > 0 && (verr != nil || validator.ifRange() == "") {
+		// without a validator the .part file may hold another database
+		s.logf("Cannot resume the download, starting over\n")
+		removePart(partPath)
+		offset = 0
+	}
+	headers := map[string]string{}
+	if offset > 0 {
+		s.logf("Resuming download at %s\n", utils.FormatSize(offset))
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		headers["If-Range"] = validator.ifRange()
+	}
+	resp, err := get(headers)
+	var httpErr api.HTTPError
+	if offset > 0 && errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		if validator.matches(httpErr.Headers) && validator.Size == offset &&
+			httpErr.Headers.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) {
+			// the .part file is already complete
+			return nil
+		}
+		removePart(partPath)
+		return fmt.Errorf("failure resuming the download of `%s`, starting over", path)
+	} else if err != nil {
+		return fmt.Errorf("failure downloading the DB from `%s`: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	total := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		if !validator.matches(resp.Header) {
+			removePart(partPath)
+			return fmt.Errorf("failure resuming the download of `%s`: the database changed, starting over", path)
+		}
+		if total >= 0 {
+			total += offset
+		}
+	} else {
+		// the server ignored the range or the database changed, start over
+		flags |= os.O_TRUNC
+		offset = 0
+		validator = newValidator(resp.Header, total)
+		if err := validator.write(partPath); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	progress := utils.NewProgress(s.progressOut(), tty, label, offset, total)
+	_, err = io.Copy(io.MultiWriter(f, progress), resp.Body)
+	progress.Finish()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// partValidator identifies the version of the database a .part file holds.
+// It is stored next to the .part file.
+type partValidator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Size is the size of the complete database, when known.
+	Size int64 `json:"size,omitempty"`
+}
+
+func newValidator(header http.Header, size int64) *partValidator {
+	v := &partValidator{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+	if size > 0 {
+		v.Size = size
+	}
+	return v
+}
+
+// ifRange returns the If-Range header value, empty if the database cannot be
+// identified. Weak ETags cannot be used in If-Range.
+func (v *partValidator) ifRange() string {
+	if v.ETag != "" && !strings.HasPrefix(v.ETag, "W/") {
+		return v.ETag
+	}
+	return v.LastModified
+}
+
+// matches reports whether a response with header is for the database the
+// validator identifies.
+func (v *partValidator) matches(header http.Header) bool {
+	if v.ETag != "" && header.Get("ETag") != "" {
+		return v.ETag == header.Get("ETag")
+	}
+	if v.LastModified != "" && header.Get("Last-Modified") != "" {
+		return v.LastModified == header.Get("Last-Modified")
+	}
+	return false
+}
+
+func (v *partValidator) write(partPath string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(validatorPath(partPath), data, 0644)
+}
+
+func readValidator(partPath string) (*partValidator, error) {
+	data, err := os.ReadFile(validatorPath(partPath))
+	if err != nil {
+		return nil, err
+	}
+	var v partValidator
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func validatorPath(partPath string) string {
+	return partPath + ".json"
+}
+
+// removePart deletes a .part file and its validator.
+func removePart(partPath string) {
+	os.Remove(partPath)
+	os.Remove(validatorPath(partPath))
+}
+
+// stored returns the database already stored at path.
+func (s *Store) stored(nwo string, path string) *Database {
+	db := &Database{NWO: nwo, Path: path}
+	db.Language, db.ShortSha, _ = ParseName(filepath.Base(path))
+	if metadata, err := ReadMetadata(path); err == nil {
+		db.Metadata = metadata
+		db.CommitSha = metadata.CommitSha()
+	}
+	return db
+}
@@ -0,0 +1,180 @@
+package qldb
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/api"
+)
+
+func TestPartValidatorIfRange(t *testing.T) {
+	tests := []struct {
+		validator partValidator
+		want      string
+	}{
+		{partValidator{}, ""},
+		{partValidator{ETag: `"abc"`}, `"abc"`},
+		{partValidator{ETag: `"abc"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}, `"abc"`},
+		// weak ETags cannot be used in If-Range
+		{partValidator{ETag: `W/"abc"`}, ""},
+		{partValidator{ETag: `W/"abc"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}, "Mon, 02 Jan 2006 15:04:05 GMT"},
+		{partValidator{LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}, "Mon, 02 Jan 2006 15:04:05 GMT"},
+	}
+	for _, tt := range tests {
+		if got := tt.validator.ifRange(); got != tt.want {
+			t.Errorf("%+v.ifRange() = %q, want %q", tt.validator, got, tt.want)
+		}
+	}
+}
+
+func TestPartValidatorMatches(t *testing.T) {
+	const date = "Mon, 02 Jan 2006 15:04:05 GMT"
+	tests := []struct {
+		validator partValidator
+		header    http.Header
+		want      bool
+	}{
+		{partValidator{ETag: `"abc"`}, http.Header{"Etag": {`"abc"`}}, true},
+		{partValidator{ETag: `"abc"`}, http.Header{"Etag": {`"def"`}}, false},
+		// the ETag takes precedence over the modification date
+		{partValidator{ETag: `"abc"`, LastModified: date}, http.Header{"Etag": {`"def"`}, "Last-Modified": {date}}, false},
+		{partValidator{ETag: `"abc"`, LastModified: date}, http.Header{"Last-Modified": {date}}, true},
+		{partValidator{LastModified: date}, http.Header{"Last-Modified": {date}}, true},
+		{partValidator{LastModified: date}, http.Header{"Last-Modified": {"Tue, 03 Jan 2006 15:04:05 GMT"}}, false},
+		{partValidator{ETag: `"abc"`}, http.Header{}, false},
+		{partValidator{}, http.Header{"Etag": {`"abc"`}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.validator.matches(tt.header); got != tt.want {
+			t.Errorf("%+v.matches(%v) = %v, want %v", tt.validator, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPart(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	const etag = `"v2"`
+	tests := []struct {
+		name string
+		// part is the content of the .part file, validator the validator
+		// stored next to it
+		part      []byte
+		validator *partValidator
+		wantRange bool
+		wantErr   bool
+		// want is the content of the .part file after fetching
+		want []byte
+	}{
+		{name: "new download", want: content},
+		{
+			name:      "resume",
+			part:      content[:300],
+			validator: &partValidator{ETag: etag, Size: int64(len(content))},
+			wantRange: true,
+			want:      content,
+		},
+		{
+			name:      "database changed",
+			part:      []byte(strings.Repeat("x", 300)),
+			validator: &partValidator{ETag: `"v1"`, Size: int64(len(content))},
+			wantRange: true,
+			want:      content,
+		},
+		{
+			name:      "weak etag",
+			part:      []byte(strings.Repeat("x", 300)),
+			validator: &partValidator{ETag: `W/"v2"`},
+			want:      content,
+		},
+		{
+			name: "no validator",
+			part: []byte(strings.Repeat("x", 300)),
+			want: content,
+		},
+		{
+			name:      "already complete",
+			part:      content,
+			validator: &partValidator{ETag: etag, Size: int64(len(content))},
+			wantRange: true,
+			want:      content,
+		},
+		{
+			name:      "larger than the database",
+			part:      append(append([]byte{}, content...), 'x'),
+			validator: &partValidator{ETag: etag, Size: int64(len(content)) + 1},
+			wantRange: true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRange bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange = r.Header.Get("Range") != ""
+				w.Header().Set("ETag", etag)
+				http.ServeContent(w, r, "db.zip", time.Time{}, bytes.NewReader(content))
+			}))
+			defer server.Close()
+			get := func(headers map[string]string) (*http.Response, error) {
+				client, err := gh.RESTClient(&api.ClientOptions{
+					Host:      "github.com",
+					AuthToken: "token",
+					Transport: http.DefaultTransport,
+					Headers:   headers,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return client.Request("GET", server.URL, nil)
+			}
+
+			s := NewStore(t.TempDir(), "github.com")
+			partPath := filepath.Join(s.Root, ".java.zip.part")
+			if tt.part != nil {
+				if err := os.WriteFile(partPath, tt.part, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.validator != nil {
+				if err := tt.validator.write(partPath); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := s.fetchPart(get, server.URL, "foo/bar", partPath, false)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("fetchPart returned %v", err)
+			}
+			if gotRange != tt.wantRange {
+				t.Errorf("range requested = %v, want %v", gotRange, tt.wantRange)
+			}
+			if tt.wantErr {
+				if exists(partPath) || exists(validatorPath(partPath)) {
+					t.Errorf("the .part file was not removed")
+				}
+				return
+			}
+			got, err := os.ReadFile(partPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf(".part file has %d bytes, want %d", len(got), len(tt.want))
+			}
+			validator, err := readValidator(partPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if validator.ETag != etag {
+				t.Errorf("validator ETag is %q, want %q", validator.ETag, etag)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package qldb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnrecognized is returned for files and directories in the QLDB structure
+// that are not named like databases.
+var ErrUnrecognized = errors.New("unrecognized database name")
+
+// legacySeparator separates the language and commit SHA in the names of
+// databases stored by early versions of gh-qldb.
+const legacySeparator = "─"
+
+// Identity identifies a database in the QLDB structure by the repository,
+// primary language and commit it was created from.
+type Identity struct {
+	NWO      string
+	Language string
+	ShortSha string
+}
+
+// Name returns the file name of the zipped database, <language>-<sha>.zip.
+func (id Identity) Name() string {
+	return fmt.Sprintf("%s-%s.zip", id.Language, shortSha(id.ShortSha))
+}
+
+// ParseName parses the file or directory name of a database into its
+// language and short commit SHA. The language can contain hyphens, as in
+// c-cpp-0123abcd.zip, and the legacy <language>─<sha> names are recognized
+// too. Other names return ErrUnrecognized.
+func ParseName(name string) (language string, sha string, err error) {
+	base := trimDBExt(name)
+	i := strings.LastIndex(base, "-")
+	sep := "-"
+	if j := strings.LastIndex(base, legacySeparator); j > i {
+		i, sep = j, legacySeparator
+	}
+	if i <= 0 {
+		return "", "", fmt.Errorf("%w: %s", ErrUnrecognized, name)
+	}
+	language, sha = base[:i], base[i+len(sep):]
+	if !isSha(sha) {
+		return "", "", fmt.Errorf("%w: %s", ErrUnrecognized, name)
+	}
+	return language, strings.ToLower(sha), nil
+}
+
+// ParsePath parses the path of a database stored in the QLDB structure, at
+// <owner>/<repo>/<name>, into its identity.
+func ParsePath(path string) (Identity, error) {
+	// directory paths may end in a separator
+	path = filepath.Clean(path)
+	language, sha, err := ParseName(filepath.Base(path))
+	if err != nil {
+		return Identity{}, err
+	}
+	repoDir := filepath.Dir(path)
+	return Identity{
+		NWO:      filepath.Base(filepath.Dir(repoDir)) + "/" + filepath.Base(repoDir),
+		Language: language,
+		ShortSha: sha,
+	}, nil
+}
+
+// isSha reports whether s looks like a full or abbreviated commit SHA.
+func isSha(s string) bool {
+	if len(s) < 4 || len(s) > 40 {
+		return false
+	}
+	for _, r := range strings.ToLower(s) {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package qldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		sha      string
+	}{
+		{"java-0123abcd.zip", "java", "0123abcd"},
+		{"c-cpp-0123abcd.zip", "c-cpp", "0123abcd"},
+		{"java─0123abcd.zip", "java", "0123abcd"},
+		{"c-cpp─0123abcd.zip", "c-cpp", "0123abcd"},
+		// unpacked databases
+		{"java-0123abcd", "java", "0123abcd"},
+		{"java─0123abcd", "java", "0123abcd"},
+		{"java-0123ABCD.zip", "java", "0123abcd"},
+		{"java-0123456789abcdef0123456789abcdef01234567.zip", "java", "0123456789abcdef0123456789abcdef01234567"},
+		// the SHA is taken from the last hyphen, so any name ending in at
+		// least four hex digits is accepted
+		{"go-cafe", "go", "cafe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, sha, err := ParseName(tt.name)
+			if err != nil {
+				t.Fatalf("ParseName(%q) returned %v", tt.name, err)
+			}
+			if language != tt.language || sha != tt.sha {
+				t.Errorf("ParseName(%q) = %q, %q, want %q, %q", tt.name, language, sha, tt.language, tt.sha)
+			}
+		})
+	}
+}
+
+func TestParseNameUnrecognized(t *testing.T) {
+	names := []string{
+		"",
+		"notes.txt",
+		"java.zip",
+		"java-",
+		"-0123abcd.zip",
+		"─0123abcd.zip",
+		"java-abc.zip",
+		"java-xyz12345.zip",
+		"java-0123abcd.results",
+		"java-0123456789abcdef0123456789abcdef012345678.zip",
+		".qldb-index.json",
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseName(name); !errors.Is(err, ErrUnrecognized) {
+				t.Errorf("ParseName(%q) returned %v, want ErrUnrecognized", name, err)
+			}
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Identity
+	}{
+		{"/root/github.com/apache/logging-log4j2/java-0123abcd.zip", Identity{NWO: "apache/logging-log4j2", Language: "java", ShortSha: "0123abcd"}},
+		{"/root/github.com/foo/baz/go-89abcdef", Identity{NWO: "foo/baz", Language: "go", ShortSha: "89abcdef"}},
+		// shell completion adds a trailing slash to directories
+		{"/root/github.com/foo/baz/go-89abcdef/", Identity{NWO: "foo/baz", Language: "go", ShortSha: "89abcdef"}},
+	}
+	for _, tt := range tests {
+		id, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatalf("ParsePath(%q) returned %v", tt.path, err)
+		}
+		if id != tt.want {
+			t.Errorf("ParsePath(%q) returned %+v, want %+v", tt.path, id, tt.want)
+		}
+	}
+	id := Identity{NWO: "apache/logging-log4j2", Language: "java", ShortSha: "0123abcd"}
+	if name := id.Name(); name != "java-0123abcd.zip" {
+		t.Errorf("Name() = %q, want java-0123abcd.zip", name)
+	}
+}
+
+func TestIsSha(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"", false},
+		{"abc", false},
+		{"cafe", true},
+		{"0123abcd", true},
+		{"0123ABCD", true},
+		{"0123456789abcdef0123456789abcdef01234567", true},
+		{"0123456789abcdef0123456789abcdef012345678", false},
+		{"0123abcg", false},
+		{"v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := isSha(tt.s); got != tt.want {
+			t.Errorf("isSha(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package qldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+const (
+	// IndexFile is the name of the index file stored in the base path.
+	IndexFile    = ".qldb-index.json"
+	indexVersion = 2
+)
+
+// index is a cache of the databases stored in the QLDB structure, used to
+// answer list and info queries without walking the tree or calling the
+// GitHub API.
+type index struct {
+	Version   int           `json:"version"`
+	Databases []*indexEntry `json:"databases"`
+	// Unrecognized lists the entries of the QLDB structure that are not
+	// named like databases.
+	Unrecognized []string `json:"unrecognized,omitempty"`
+}
+
+type indexEntry struct {
+	NWO           string                  `json:"nwo"`
+	Language      string                  `json:"language"`
+	ShortSha      string                  `json:"shortSha"`
+	CommitSha     string                  `json:"commitSha,omitempty"`
+	CommittedDate string                  `json:"committedDate,omitempty"`
+	Path          string                  `json:"path"`
+	Metadata      *utils.DatabaseMetadata `json:"metadata,omitempty"`
+}
+
+// IndexPath returns the location of the index file.
+func (s *Store) IndexPath() string {
+	return filepath.Join(s.BasePath(), IndexFile)
+}
+
+// Reindex rebuilds the index from the databases and metadata files found in
+// the QLDB structure.
+func (s *Store) Reindex() ([]*Database, error) {
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	idx, err := s.reindex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.databases(s), nil
+}
+
+// reindex rebuilds and writes the index. The caller holds the index lock.
+func (s *Store) reindex() (*index, error) {
+	dbs, unrecognized, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the commit dates already resolved from the GitHub API
+	committedDates := map[string]string{}
+	if old, err := s.readIndex(); err == nil {
+		for _, e := range old.Databases {
+			committedDates[e.Path] = e.CommittedDate
+		}
+	}
+
+	idx := &index{Version: indexVersion}
+	for _, path := range unrecognized {
+		idx.Unrecognized = append(idx.Unrecognized, s.relPath(path))
+	}
+	for _, db := range dbs {
+		e := idx.add(s, db)
+		if e.CommittedDate == "" {
+			e.CommittedDate = committedDates[e.Path]
+		}
+	}
+	if err := s.writeIndex(idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// loadIndex reads the index, building it if it does not exist yet. Entries
+// for databases that no longer exist are ignored.
+func (s *Store) loadIndex() (*index, error) {
+	idx, err := s.readIndex()
+	if errors.Is(err, os.ErrNotExist) {
+		if !exists(s.BasePath()) {
+			// an empty store, do not create its directory just to list it
+			return &index{Version: indexVersion}, nil
+		}
+		unlock, err := s.lockIndex()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		// another process may have built the index while we waited
+		if idx, err = s.readOrBuildIndex(); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	idx.removeDeleted(s)
+	return idx, nil
+}
+
+// readOrBuildIndex reads the index, building it if it does not exist yet.
+// The caller holds the index lock.
+func (s *Store) readOrBuildIndex() (*index, error) {
+	idx, err := s.readIndex()
+	if errors.Is(err, os.ErrNotExist) {
+		return s.reindex()
+	}
+	return idx, err
+}
+
+// Unrecognized returns the paths of the entries of the QLDB structure that
+// are not named like databases, and so are left out of List.
+func (s *Store) Unrecognized() ([]string, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, path := range idx.Unrecognized {
+		if path = filepath.Join(s.BasePath(), path); exists(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// updateIndex applies update to the index and writes it back. The index lock
+// is held meanwhile so concurrent updates, including those of other
+// processes sharing the QLDB root, are not lost.
+func (s *Store) updateIndex(update func(idx *index)) error {
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	idx, err := s.readOrBuildIndex()
+	if err != nil {
+		return err
+	}
+	idx.removeDeleted(s)
+	update(idx)
+	return s.writeIndex(idx)
+}
+
+// lockIndex takes the index lock: indexMu within the process and an
+// exclusive lock on the index lock file across processes.
+func (s *Store) lockIndex() (unlock func(), err error) {
+	s.indexMu.Lock()
+	defer func() {
+		if err != nil {
+			s.indexMu.Unlock()
+		}
+	}()
+	if err := os.MkdirAll(s.BasePath(), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.IndexPath()+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot lock the index: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.indexMu.Unlock()
+	}, nil
+}
+
+func (s *Store) readIndex() (*index, error) {
+	data, err := os.ReadFile(s.IndexPath())
+	if err != nil {
+		return nil, err
+	}
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil || idx.Version != indexVersion {
+		// an unreadable index is rebuilt from scratch
+		return nil, os.ErrNotExist
+	}
+	return &idx, nil
+}
+
+// writeIndex atomically replaces the index file.
+func (s *Store) writeIndex(idx *index) error {
+	sort.Slice(idx.Databases, func(i, j int) bool {
+		return idx.Databases[i].Path < idx.Databases[j].Path
+	})
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.BasePath(), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.BasePath(), IndexFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.IndexPath())
+}
+
+// removeDeleted drops the entries for databases that no longer exist.
+func (idx *index) removeDeleted(s *Store) {
+	var live []*indexEntry
+	for _, e := range idx.Databases {
+		if exists(filepath.Join(s.BasePath(), e.Path)) {
+			live = append(live, e)
+		}
+	}
+	idx.Databases = live
+}
+
+// add inserts or replaces the entry for db.
+func (idx *index) add(s *Store, db *Database) *indexEntry {
+	e := &indexEntry{
+		NWO:           db.NWO,
+		Language:      db.Language,
+		ShortSha:      db.ShortSha,
+		CommitSha:     db.CommitSha,
+		CommittedDate: db.CommittedDate,
+		Path:          s.relPath(db.Path),
+		Metadata:      db.Metadata,
+	}
+	idx.remove(s, db.Path)
+	idx.Databases = append(idx.Databases, e)
+	return e
+}
+
+// remove deletes the entry for the database at path.
+func (idx *index) remove(s *Store, path string) {
+	rel := s.relPath(path)
+	for i, e := range idx.Databases {
+		if e.Path == rel {
+			idx.Databases = append(idx.Databases[:i], idx.Databases[i+1:]...)
+			return
+		}
+	}
+}
+
+// lookup returns the database at path, or nil if it is not indexed.
+func (idx *index) lookup(s *Store, path string) *Database {
+	rel := s.relPath(path)
+	for _, e := range idx.Databases {
+		if e.Path == rel {
+			return e.database(s)
+		}
+	}
+	return nil
+}
+
+func (idx *index) databases(s *Store) []*Database {
+	var dbs []*Database
+	for _, e := range idx.Databases {
+		dbs = append(dbs, e.database(s))
+	}
+	return dbs
+}
+
+func (e *indexEntry) database(s *Store) *Database {
+	return &Database{
+		NWO:           e.NWO,
+		Language:      e.Language,
+		ShortSha:      e.ShortSha,
+		CommitSha:     e.CommitSha,
+		CommittedDate: e.CommittedDate,
+		Path:          filepath.Join(s.BasePath(), e.Path),
+		Metadata:      e.Metadata,
+	}
+}
+
+// relPath returns path relative to the base path, so the index stays valid
+// when the QLDB root is moved.
+func (s *Store) relPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(s.BasePath(), abs)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,52 @@
+package qldb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestUpdateIndexConcurrentStores(t *testing.T) {
+	// two stores on the same root share the index like two processes do
+	root := t.TempDir()
+	stores := []*Store{NewStore(root, "github.com"), NewStore(root, "github.com")}
+	const n = 20
+	var dbs []*Database
+	for i := 0; i < n; i++ {
+		db := &Database{NWO: "foo/bar", Language: "java", ShortSha: fmt.Sprintf("%08x", i)}
+		db.Path = stores[0].DatabasePath(db.NWO, db.Language, db.ShortSha)
+		if err := os.MkdirAll(filepath.Dir(db.Path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(db.Path, []byte("zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		dbs = append(dbs, db)
+	}
+	// build the index before the databases are added to it
+	if err := stores[0].writeIndex(&index{Version: indexVersion}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Add(1)
+		go func(s *Store, db *Database) {
+			defer wg.Done()
+			if err := s.updateIndex(func(idx *index) { idx.add(s, db) }); err != nil {
+				t.Error(err)
+			}
+		}(stores[i%len(stores)], db)
+	}
+	wg.Wait()
+
+	idx, err := stores[0].loadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Databases) != n {
+		t.Errorf("index has %d databases, want %d", len(idx.Databases), n)
+	}
+}
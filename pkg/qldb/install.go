@@ -0,0 +1,224 @@
+package qldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// InstallOptions controls how Install handles the source database.
+type InstallOptions struct {
+	// Remove deletes the source database once it has been installed.
+	Remove bool
+	// CommitSha is the commit the database was created from. It is used
+	// when the database metadata does not record one.
+	CommitSha string
+	// Build describes how the database was built. It is recorded in the
+	// metadata file.
+	Build *utils.BuildMetadata
+}
+
+// Install copies the database at dbPath, either a database directory or a
+// zip file, into the QLDB structure under nwo.
+func (s *Store) Install(nwo string, dbPath string, opts InstallOptions) (*Database, error) {
+	s.logf("Installing '%s' database for '%s'\n", dbPath, nwo)
+
+	// Check if the path exists
+	fileinfo, err := os.Stat(dbPath)
+	if os.IsNotExist(err) {
+		return nil, errors.New("Database path does not exist")
+	} else if err != nil {
+		return nil, err
+	}
+
+	var zipPath string
+	if fileinfo.IsDir() {
+		s.logf("Validating '%s' database\n", dbPath)
+		if err := utils.ValidateDB(dbPath); err != nil {
+			return nil, fmt.Errorf("database is not valid: %w", err)
+		}
+		// Compress DB
+		tmpdir, err := os.MkdirTemp("", "qldb")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpdir)
+		zipPath = filepath.Join(tmpdir, "qldb.zip")
+		s.logf("Compressing database\n")
+		if err := utils.ZipDirectory(zipPath, dbPath); err != nil {
+			return nil, err
+		}
+	} else {
+		// Check if the file is a zip
+		if !strings.HasSuffix(dbPath, ".zip") {
+			return nil, errors.New("Database is not a zip file")
+		}
+		zipPath = dbPath
+
+		// Unzip to a temporary directory
+		tmpdir, err := os.MkdirTemp("", "qldb")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpdir)
+		if _, err := utils.Unzip(dbPath, tmpdir); err != nil {
+			return nil, err
+		}
+
+		dbDir := databaseRoot(tmpdir)
+		s.logf("Validating '%s' database\n", dbDir)
+		if err := utils.ValidateDB(dbDir); err != nil {
+			s.logf("Database is not valid\n")
+		}
+	}
+
+	s.logf("Extracting database information ... ")
+	metadata, err := utils.ReadDatabaseMetadata(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.CommitSha() == "" && opts.CommitSha != "" {
+		if metadata.CreationMetadata == nil {
+			metadata.CreationMetadata = &utils.CreationMetadata{}
+		}
+		metadata.CreationMetadata.Sha = opts.CommitSha
+	}
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+	metadata.Provenance = nwo
+	metadata.Build = opts.Build
+	s.logf("\nCommit SHA: %s\n", metadata.CommitSha())
+	s.logf("Short Commit SHA: %s\n", metadata.ShortCommitSha())
+	s.logf("Primary language: %s\n", metadata.PrimaryLanguage)
+
+	zipDestPath := s.DatabasePath(nwo, metadata.PrimaryLanguage, metadata.CommitSha())
+	s.logf("Installing database to '%s'\n", zipDestPath)
+
+	// Check if the DB is already installed
+	fresh := !exists(zipDestPath)
+	if fresh {
+		if err := os.MkdirAll(filepath.Dir(zipDestPath), 0755); err != nil {
+			return nil, err
+		}
+		srcFile, err := os.Open(zipPath)
+		if err != nil {
+			return nil, err
+		}
+		n, err := writeAtomic(zipDestPath, srcFile)
+		srcFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		s.logf("Copied %d bytes\n", n)
+	} else {
+		s.logf("Database already installed for same commit\n")
+	}
+
+	metadata, err = s.writeMetadata(zipDestPath, metadata, fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove DB from the current location if requested
+	if opts.Remove {
+		s.logf("Removing database from '%s'\n", dbPath)
+		if err := os.RemoveAll(dbPath); err != nil {
+			return nil, err
+		}
+	}
+
+	db := newDatabase(nwo, zipDestPath, metadata)
+	if err := s.updateIndex(func(idx *index) { idx.add(s, db) }); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// writeMetadata writes the metadata file for the database at dbPath and
+// returns the metadata it holds. When the database was just written, fresh is
+// set and metadata describes it: only the results and build record of an
+// existing metadata file are kept. Otherwise an existing metadata file is
+// kept, along with the results recorded in it, and only its build record is
+// replaced. The checksum is added to metadata files written before checksums
+// were recorded.
+func (s *Store) writeMetadata(dbPath string, metadata *utils.DatabaseMetadata, fresh bool) (*utils.DatabaseMetadata, error) {
+	jsonPath := MetadataPath(dbPath)
+	if existing, err := ReadMetadata(dbPath); err == nil {
+		s.logf("Database metadata %s already exists\n", jsonPath)
+		if fresh {
+			// the metadata file describes a database that was replaced
+			metadata.Results = existing.Results
+			if metadata.Build == nil {
+				metadata.Build = existing.Build
+			}
+			metadata.Sha256, metadata.Size = "", 0
+		} else {
+			if metadata.Build == nil && existing.Sha256 != "" {
+				return existing, nil
+			}
+			if metadata.Build != nil {
+				existing.Build = metadata.Build
+			}
+			metadata = existing
+		}
+	} else if !os.IsNotExist(err) {
+		s.logf("Replacing database metadata %s: %v\n", jsonPath, err)
+	}
+	if metadata.Sha256 == "" {
+		sum, size, err := utils.HashFile(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Sha256, metadata.Size = sum, size
+	}
+	jsonData, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(jsonPath, jsonData, 0644); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func newDatabase(nwo string, path string, metadata *utils.DatabaseMetadata) *Database {
+	return &Database{
+		NWO:       nwo,
+		Language:  metadata.PrimaryLanguage,
+		ShortSha:  metadata.ShortCommitSha(),
+		CommitSha: metadata.CommitSha(),
+		Path:      path,
+		Metadata:  metadata,
+	}
+}
+
+// writeAtomic streams r into a temporary file next to dst and renames it to
+// dst once it has been completely written.
+func writeAtomic(dst string, r io.Reader) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,154 @@
+package qldb
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+func TestWriteMetadataUpdatesBuild(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	dbPath := filepath.Join(s.BasePath(), "foo", "bar", "java-0123abcd.zip")
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath, []byte("zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first := &utils.DatabaseMetadata{
+		PrimaryLanguage: "java",
+		Build:           &utils.BuildMetadata{Profile: "first", Args: []string{"-j1"}},
+	}
+	if _, err := s.writeMetadata(dbPath, first, true); err != nil {
+		t.Fatal(err)
+	}
+	// record results like AddResult does
+	if err := s.updateMetadata(&Database{Path: dbPath}, func(metadata *utils.DatabaseMetadata) {
+		metadata.Results = []utils.AnalysisMetadata{{Name: "security"}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &utils.BuildMetadata{Profile: "second", Args: []string{"-j4"}}
+	metadata, err := s.writeMetadata(dbPath, &utils.DatabaseMetadata{PrimaryLanguage: "java", Build: second}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(metadata.Build, second) {
+		t.Errorf("writeMetadata returned build %+v, want %+v", metadata.Build, second)
+	}
+	stored, err := ReadMetadata(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored.Build, second) {
+		t.Errorf("stored build is %+v, want %+v", stored.Build, second)
+	}
+	if stored.Sha256 == "" || stored.Size != 3 {
+		t.Errorf("stored metadata has sha256 %q and size %d", stored.Sha256, stored.Size)
+	}
+	if len(stored.Results) != 1 {
+		t.Errorf("stored metadata has %d results, want 1", len(stored.Results))
+	}
+}
+
+func TestWriteMetadataAddsChecksum(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	dbPath := filepath.Join(s.BasePath(), "foo", "bar", "java-0123abcd.zip")
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath, []byte("zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// a sidecar written before checksums were recorded
+	if err := os.WriteFile(MetadataPath(dbPath), []byte(`{"primaryLanguage":"java"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.writeMetadata(dbPath, &utils.DatabaseMetadata{PrimaryLanguage: "java"}, false); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ReadMetadata(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Sha256 == "" || stored.Size != 3 {
+		t.Errorf("stored metadata has sha256 %q and size %d", stored.Sha256, stored.Size)
+	}
+}
+
+func TestInstallReplacesOrphanedMetadata(t *testing.T) {
+	s := NewStore(t.TempDir(), "github.com")
+	src := t.TempDir()
+	const sha = "0123abcd0123abcd0123abcd0123abcd0123abcd"
+
+	first := filepath.Join(src, "first.zip")
+	writeDatabaseZip(t, first, sha, "2.15.0", 10)
+	db, err := s.Install("foo/bar", first, InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.updateMetadata(db, func(metadata *utils.DatabaseMetadata) {
+		metadata.Results = []utils.AnalysisMetadata{{Name: "security"}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// the zip is deleted by hand, leaving its metadata file behind
+	if err := os.Remove(db.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	second := filepath.Join(src, "second.zip")
+	writeDatabaseZip(t, second, sha, "2.16.0", 1000)
+	db, err = s.Install("foo/bar", second, InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ReadMetadata(db.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := stored.CreationMetadata.CliVersion; v != "2.16.0" {
+		t.Errorf("stored metadata has cliVersion %s, want 2.16.0", v)
+	}
+	if len(stored.Results) != 1 {
+		t.Errorf("stored metadata has %d results, want 1", len(stored.Results))
+	}
+	if r := s.Verify(db, VerifyOptions{}); r.Status != Verified {
+		t.Errorf("Verify returned %s: %v", r.Status, r.Err)
+	}
+}
+
+// writeDatabaseZip writes a zipped database for the given commit and CodeQL
+// version, padded with size bytes of data.
+func writeDatabaseZip(t *testing.T, path string, sha string, cliVersion string, size int) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	yml, err := w.Create("db/codeql-database.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(yml, "primaryLanguage: java\ncreationMetadata:\n  sha: %s\n  cliVersion: %s\n", sha, cliVersion)
+	data, err := w.Create("db/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := data.Write(make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package qldb
+
+import "os"
+
+// lockFile does nothing on platforms without advisory file locks, updates to
+// the index are then only serialized within a process.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
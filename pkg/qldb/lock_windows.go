@@ -0,0 +1,22 @@
+//go:build windows
+
+package qldb
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockRange is the number of bytes locked, the whole file for our purposes.
+const lockRange = ^uint32(0)
+
+func lockFile(f *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, lockRange, lockRange, &overlapped)
+}
+
+func unlockFile(f *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, lockRange, lockRange, &overlapped)
+}
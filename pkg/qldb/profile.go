@@ -0,0 +1,180 @@
+package qldb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BuildProfile is a named set of `codeql database create` options from the
+// configuration file.
+type BuildProfile struct {
+	// Name is the key of the profile in the configuration file.
+	Name string `yaml:"-"`
+	// NWO and Language select the databases the profile is applied to when
+	// no profile is requested by name. NWO can be a glob pattern, as in
+	// ListOptions, and Language must be one of the languages passed to
+	// CodeQL. Profiles without either are only applied by name.
+	NWO      string `yaml:"nwo"`
+	Language string `yaml:"language"`
+	// BuildMode, Command, Threads and Ram set the CodeQL options of the same
+	// name. Ram is in MB.
+	BuildMode string `yaml:"buildMode"`
+	Command   string `yaml:"command"`
+	Threads   *int   `yaml:"threads"`
+	Ram       int    `yaml:"ram"`
+	// ExtractorOptions are passed as --extractor-option, eg:
+	// java.buildless=true.
+	ExtractorOptions []string `yaml:"extractorOptions"`
+	// Args are additional arguments for `codeql database create`.
+	Args []string `yaml:"args"`
+}
+
+// Profile returns the build profile to use when creating a database for nwo
+// with the CodeQL arguments args. The profile called name is returned when
+// name is not empty. Otherwise the most specific profile matching the NWO and
+// the languages in args is returned, with ties broken by name, or nil if none
+// matches.
+func (c *Config) Profile(name string, nwo string, args []string) (*BuildProfile, error) {
+	if name != "" {
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("build profile not found: %s", name)
+		}
+		return profile, nil
+	}
+	languages := languageArgs(args)
+	var best *BuildProfile
+	bestScore := 0
+	for _, profile := range c.sortedProfiles() {
+		score := 0
+		if profile.NWO != "" {
+			if !matchNWO(nwo, profile.NWO) {
+				continue
+			}
+			score += 2
+		}
+		if profile.Language != "" {
+			if !contains(languages, profile.Language) {
+				continue
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = profile, score
+		}
+	}
+	return best, nil
+}
+
+func (c *Config) sortedProfiles() []*BuildProfile {
+	var profiles []*BuildProfile
+	for _, profile := range c.Profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// CodeQLArgs returns the `codeql database create` arguments of the profile.
+func (p *BuildProfile) CodeQLArgs() []string {
+	var args []string
+	if p.BuildMode != "" {
+		args = append(args, "--build-mode="+p.BuildMode)
+	}
+	if p.Command != "" {
+		args = append(args, "--command="+p.Command)
+	}
+	if p.Threads != nil {
+		args = append(args, "--threads="+strconv.Itoa(*p.Threads))
+	}
+	if p.Ram != 0 {
+		args = append(args, "--ram="+strconv.Itoa(p.Ram))
+	}
+	for _, option := range p.ExtractorOptions {
+		args = append(args, "--extractor-option="+option)
+	}
+	return append(args, p.Args...)
+}
+
+// Apply returns the profile arguments followed by args. The profile options
+// also set in args are left out, so args override the profile.
+func (p *BuildProfile) Apply(args []string) []string {
+	overridden := map[string]bool{}
+	for _, o := range parseOptions(args) {
+		overridden[o.key()] = true
+	}
+	var merged []string
+	for _, o := range parseOptions(p.CodeQLArgs()) {
+		if !overridden[o.key()] {
+			merged = append(merged, o.args...)
+		}
+	}
+	return append(merged, args...)
+}
+
+// shortOptions maps the short `codeql database create` options to their long
+// names.
+var shortOptions = map[string]string{
+	"-c": "--command",
+	"-j": "--threads",
+	"-l": "--language",
+	"-M": "--ram",
+	"-O": "--extractor-option",
+	"-s": "--source-root",
+}
+
+// option is an option in a CodeQL command line along with its values.
+type option struct {
+	// name is the long name of the option, empty for positional arguments.
+	name   string
+	values []string
+	args   []string
+}
+
+// key identifies the option for overriding. Extractor options are told apart
+// by the option they set.
+func (o option) key() string {
+	if o.name == "--extractor-option" && len(o.values) > 0 {
+		key, _, _ := strings.Cut(o.values[0], "=")
+		return o.name + ":" + key
+	}
+	return o.name
+}
+
+// parseOptions groups args into options. The values of an option are given
+// after "=" or as the following arguments.
+func parseOptions(args []string) []option {
+	var options []option
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if len(options) == 0 {
+				options = append(options, option{})
+			}
+			o := &options[len(options)-1]
+			o.values = append(o.values, arg)
+			o.args = append(o.args, arg)
+			continue
+		}
+		var name, value string
+		var hasValue bool
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			// attached short option value, eg: -j4
+			name, value, hasValue = arg[:2], arg[2:], true
+		} else {
+			name, value, hasValue = strings.Cut(arg, "=")
+		}
+		if long, ok := shortOptions[name]; ok {
+			name = long
+		}
+		o := option{name: name, args: []string{arg}}
+		if hasValue {
+			o.values = []string{value}
+		}
+		options = append(options, o)
+	}
+	return options
+}
@@ -0,0 +1,88 @@
+package qldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	options := parseOptions([]string{"-j4", "-l", "java", "--ram=2048", "-O", "java.buildless=true", "--overwrite"})
+	want := []option{
+		{name: "--threads", values: []string{"4"}, args: []string{"-j4"}},
+		{name: "--language", values: []string{"java"}, args: []string{"-l", "java"}},
+		{name: "--ram", values: []string{"2048"}, args: []string{"--ram=2048"}},
+		{name: "--extractor-option", values: []string{"java.buildless=true"}, args: []string{"-O", "java.buildless=true"}},
+		{name: "--overwrite", args: []string{"--overwrite"}},
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("parseOptions returned %+v, want %+v", options, want)
+	}
+}
+
+func TestOptionKey(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-j4"}, "--threads"},
+		{[]string{"--threads", "4"}, "--threads"},
+		{[]string{"-M", "2048"}, "--ram"},
+		{[]string{"-O", "java.buildless=true"}, "--extractor-option:java.buildless"},
+		{[]string{"--extractor-option=java.buildless=true"}, "--extractor-option:java.buildless"},
+		{[]string{"--extractor-option"}, "--extractor-option"},
+	}
+	for _, tt := range tests {
+		options := parseOptions(tt.args)
+		if len(options) != 1 {
+			t.Fatalf("parseOptions(%q) returned %d options", tt.args, len(options))
+		}
+		if got := options[0].key(); got != tt.want {
+			t.Errorf("key of %q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	threads := 0
+	profile := &BuildProfile{
+		BuildMode:        "manual",
+		Command:          "make",
+		Threads:          &threads,
+		Ram:              4096,
+		ExtractorOptions: []string{"java.buildless=false", "java.maven=true"},
+		Args:             []string{"--overwrite"},
+	}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "no overrides",
+			args: []string{"-l", "java"},
+			want: []string{"--build-mode=manual", "--command=make", "--threads=0", "--ram=4096", "--extractor-option=java.buildless=false", "--extractor-option=java.maven=true", "--overwrite", "-l", "java"},
+		},
+		{
+			name: "short options",
+			args: []string{"-j4", "-c", "mvn package", "-M", "2048"},
+			want: []string{"--build-mode=manual", "--extractor-option=java.buildless=false", "--extractor-option=java.maven=true", "--overwrite", "-j4", "-c", "mvn package", "-M", "2048"},
+		},
+		{
+			name: "long options",
+			args: []string{"--build-mode", "none", "--threads=2"},
+			want: []string{"--command=make", "--ram=4096", "--extractor-option=java.buildless=false", "--extractor-option=java.maven=true", "--overwrite", "--build-mode", "none", "--threads=2"},
+		},
+		{
+			name: "extractor options by name",
+			args: []string{"-O", "java.buildless=true"},
+			want: []string{"--build-mode=manual", "--command=make", "--threads=0", "--ram=4096", "--extractor-option=java.maven=true", "--overwrite", "-O", "java.buildless=true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profile.Apply(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
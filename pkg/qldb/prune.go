@@ -0,0 +1,129 @@
+package qldb
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// PruneOptions is the retention policy applied by Prune. Zero values disable
+// the corresponding limit.
+type PruneOptions struct {
+	// Keep is the number of databases to keep per repository and language.
+	Keep int
+	// OlderThan selects the databases created before now minus OlderThan.
+	OlderThan time.Duration
+	// MaxSize is the total size in bytes the remaining databases may take.
+	// The oldest databases are selected until the budget is met.
+	MaxSize int64
+}
+
+// PruneCandidate is a database selected for removal by Prune.
+type PruneCandidate struct {
+	*Database
+	// CreatedAt is the creation time of the database used for ordering.
+	CreatedAt time.Time
+	// Size is the size of the database in bytes.
+	Size int64
+}
+
+// Prune returns the databases that should be removed to satisfy the retention
+// policy, oldest first. Databases are ordered by the creation time found in
+// their metadata file, falling back to the file modification time for
+// databases without metadata. Nothing is removed.
+func (s *Store) Prune(opts PruneOptions) ([]*PruneCandidate, error) {
+	dbs, err := s.List(ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var all []*PruneCandidate
+	groups := map[string][]*PruneCandidate{}
+	for _, db := range dbs {
+		c, err := newPruneCandidate(db)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, c)
+		key := db.NWO + "/" + db.Language
+		groups[key] = append(groups[key], c)
+	}
+
+	selected := map[*PruneCandidate]bool{}
+	if opts.Keep > 0 {
+		for _, group := range groups {
+			sortNewestFirst(group)
+			for _, c := range group[min(opts.Keep, len(group)):] {
+				selected[c] = true
+			}
+		}
+	}
+	if opts.OlderThan > 0 {
+		cutoff := time.Now().Add(-opts.OlderThan)
+		for _, c := range all {
+			if c.CreatedAt.Before(cutoff) {
+				selected[c] = true
+			}
+		}
+	}
+
+	sortNewestFirst(all)
+	if opts.MaxSize > 0 {
+		var total int64
+		for _, c := range all {
+			if selected[c] {
+				continue
+			}
+			total += c.Size
+			if total > opts.MaxSize {
+				selected[c] = true
+			}
+		}
+	}
+
+	var results []*PruneCandidate
+	for i := len(all) - 1; i >= 0; i-- {
+		if selected[all[i]] {
+			results = append(results, all[i])
+		}
+	}
+	return results, nil
+}
+
+func newPruneCandidate(db *Database) (*PruneCandidate, error) {
+	size, err := diskUsage(db.Path)
+	if err != nil {
+		return nil, err
+	}
+	createdAt, err := creationTime(db)
+	if err != nil {
+		return nil, err
+	}
+	return &PruneCandidate{Database: db, CreatedAt: createdAt, Size: size}, nil
+}
+
+// creationTime returns the creation time recorded in the metadata of db, or
+// the modification time of the database when there is none.
+func creationTime(db *Database) (time.Time, error) {
+	if db.Metadata != nil && db.Metadata.CreationMetadata != nil && !db.Metadata.CreationMetadata.CreationTime.IsZero() {
+		return db.Metadata.CreationMetadata.CreationTime, nil
+	}
+	fi, err := os.Stat(db.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+func sortNewestFirst(candidates []*PruneCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+	})
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
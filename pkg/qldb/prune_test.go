@@ -0,0 +1,85 @@
+package qldb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	dbs := []struct {
+		path string
+		age  time.Duration
+		// metadata is false for databases dated by their modification time
+		metadata bool
+	}{
+		{"foo/bar/java-0000000a.zip", 1 * day, true},
+		{"foo/bar/java-0000000b.zip", 2 * day, true},
+		{"foo/bar/java-0000000c.zip", 3 * day, false},
+		{"foo/bar/go-0000000d.zip", 5 * day, true},
+		{"foo/baz/java-0000000e.zip", 10 * day, true},
+	}
+	tests := []struct {
+		name string
+		opts PruneOptions
+		want []string
+	}{
+		{"no limits", PruneOptions{}, nil},
+		{"keep 1", PruneOptions{Keep: 1}, []string{"foo/bar/java-0000000c.zip", "foo/bar/java-0000000b.zip"}},
+		{"keep 2", PruneOptions{Keep: 2}, []string{"foo/bar/java-0000000c.zip"}},
+		{"keep more than stored", PruneOptions{Keep: 5}, nil},
+		{"older than 4 days", PruneOptions{OlderThan: 4 * day}, []string{"foo/baz/java-0000000e.zip", "foo/bar/go-0000000d.zip"}},
+		{"older than modification time", PruneOptions{OlderThan: 60 * time.Hour}, []string{"foo/baz/java-0000000e.zip", "foo/bar/go-0000000d.zip", "foo/bar/java-0000000c.zip"}},
+		{"max size", PruneOptions{MaxSize: 25}, []string{"foo/baz/java-0000000e.zip", "foo/bar/go-0000000d.zip", "foo/bar/java-0000000c.zip"}},
+		{"max size fits", PruneOptions{MaxSize: 50}, nil},
+		{"keep and max size", PruneOptions{Keep: 1, MaxSize: 15}, []string{"foo/baz/java-0000000e.zip", "foo/bar/go-0000000d.zip", "foo/bar/java-0000000c.zip", "foo/bar/java-0000000b.zip"}},
+	}
+
+	s := NewStore(t.TempDir(), "github.com")
+	now := time.Now()
+	for _, db := range dbs {
+		path := filepath.Join(s.BasePath(), db.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+			t.Fatal(err)
+		}
+		created := now.Add(-db.age)
+		if db.metadata {
+			data, err := json.Marshal(&utils.DatabaseMetadata{
+				CreationMetadata: &utils.CreationMetadata{CreationTime: created},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(MetadataPath(path), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := os.Chtimes(path, created, created); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := s.Prune(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range candidates {
+				got = append(got, s.relPath(c.Path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Prune(%+v) = %v, want %v", tt.opts, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package qldb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Remove deletes the database at path, either a zip file or a database
+// directory, together with its metadata file and analysis results. The
+// repository and owner directories are deleted too when they are left empty,
+// save for the logs of failed create runs. Paths that are not databases
+// stored at <owner>/<repo>/<name> return ErrUnrecognized.
+func (s *Store) Remove(path string) error {
+	base := s.BasePath()
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s is not stored in %s", path, base)
+	}
+	if len(strings.Split(filepath.ToSlash(rel), "/")) != 3 {
+		return fmt.Errorf("%w: %s", ErrUnrecognized, path)
+	}
+	if !exists(path) {
+		return ErrNotFound
+	}
+	if _, err := s.inspect(path); err != nil {
+		return err
+	}
+	s.logf("Removing '%s'\n", path)
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	if err := os.Remove(MetadataPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.RemoveAll(ResultsPath(path)); err != nil {
+		return err
+	}
+	if err := s.removeUnpacked(path); err != nil {
+		return err
+	}
+	if err := s.updateIndex(func(idx *index) { idx.remove(s, path) }); err != nil {
+		return err
+	}
+	if err := removeCreateLogs(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return s.pruneEmptyDirs(filepath.Dir(path))
+}
+
+// removeCreateLogs deletes the create-<language>.log files written by Create
+// in the repository directory dir when it holds nothing else.
+func removeCreateLogs(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var logs []string
+	for _, e := range entries {
+		if e.IsDir() || !isCreateLog(e.Name()) {
+			return nil
+		}
+		logs = append(logs, filepath.Join(dir, e.Name()))
+	}
+	for _, log := range logs {
+		if err := os.Remove(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isCreateLog(name string) bool {
+	return strings.HasPrefix(name, "create-") && strings.HasSuffix(name, ".log")
+}
+
+// pruneEmptyDirs removes dir and its parents while they are empty, stopping
+// at the base path.
+func (s *Store) pruneEmptyDirs(dir string) error {
+	base := filepath.Clean(s.BasePath())
+	for dir = filepath.Clean(dir); dir != base && strings.HasPrefix(dir, base); dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package qldb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name string
+		// files are created relative to the base path
+		files []string
+		path  string
+		// wantErr is a substring of the expected error
+		wantErr string
+		gone    []string
+		kept    []string
+	}{
+		{
+			name:  "zip with metadata and results",
+			files: []string{"foo/bar/java-0123abcd.zip", "foo/bar/java-0123abcd.json", "foo/bar/java-0123abcd.results/security.sarif"},
+			path:  "foo/bar/java-0123abcd.zip",
+			gone:  []string{"foo/bar/java-0123abcd.zip", "foo/bar/java-0123abcd.json", "foo/bar/java-0123abcd.results", "foo"},
+		},
+		{
+			name:  "database directory",
+			files: []string{"foo/bar/java-0123abcd/codeql-database.yml", "foo/bar/java-0123abcd.json"},
+			path:  "foo/bar/java-0123abcd",
+			gone:  []string{"foo/bar/java-0123abcd", "foo/bar/java-0123abcd.json", "foo"},
+		},
+		{
+			name:  "other databases keep the repository directory",
+			files: []string{"foo/bar/java-0123abcd.zip", "foo/bar/go-0123abcd.zip", "foo/baz/java-0123abcd.zip"},
+			path:  "foo/bar/java-0123abcd.zip",
+			gone:  []string{"foo/bar/java-0123abcd.zip"},
+			kept:  []string{"foo/bar/go-0123abcd.zip", "foo/baz/java-0123abcd.zip"},
+		},
+		{
+			name:  "other repositories keep the owner directory",
+			files: []string{"foo/bar/java-0123abcd.zip", "foo/baz/java-0123abcd.zip"},
+			path:  "foo/bar/java-0123abcd.zip",
+			gone:  []string{"foo/bar"},
+			kept:  []string{"foo/baz/java-0123abcd.zip"},
+		},
+		{
+			name:  "logs of failed create runs",
+			files: []string{"foo/bar/java-0123abcd.zip", "foo/bar/create-java.log", "foo/bar/create-java+go.log"},
+			path:  "foo/bar/java-0123abcd.zip",
+			gone:  []string{"foo"},
+		},
+		{
+			name:  "logs are kept with other databases",
+			files: []string{"foo/bar/java-0123abcd.zip", "foo/bar/go-0123abcd.zip", "foo/bar/create-java.log"},
+			path:  "foo/bar/java-0123abcd.zip",
+			kept:  []string{"foo/bar/go-0123abcd.zip", "foo/bar/create-java.log"},
+		},
+		{
+			name:    "outside the base path",
+			files:   []string{"../other.example.com/foo/bar/java-0123abcd.zip"},
+			path:    "../other.example.com/foo/bar/java-0123abcd.zip",
+			wantErr: "is not stored in",
+			kept:    []string{"../other.example.com/foo/bar/java-0123abcd.zip"},
+		},
+		{
+			name:    "base path",
+			files:   []string{"foo/bar/java-0123abcd.zip"},
+			path:    ".",
+			wantErr: "is not stored in",
+			kept:    []string{"foo/bar/java-0123abcd.zip"},
+		},
+		{
+			name:    "repository directory",
+			files:   []string{"foo/bar/java-0123abcd.zip"},
+			path:    "foo/bar",
+			wantErr: ErrUnrecognized.Error(),
+			kept:    []string{"foo/bar/java-0123abcd.zip"},
+		},
+		{
+			name:    "too deep",
+			files:   []string{"foo/bar/baz/java-0123abcd.zip"},
+			path:    "foo/bar/baz/java-0123abcd.zip",
+			wantErr: ErrUnrecognized.Error(),
+			kept:    []string{"foo/bar/baz/java-0123abcd.zip"},
+		},
+		{
+			name:    "not a database",
+			files:   []string{"foo/bar/notes.txt"},
+			path:    "foo/bar/notes.txt",
+			wantErr: ErrUnrecognized.Error(),
+			kept:    []string{"foo/bar/notes.txt"},
+		},
+		{
+			name:    "missing database",
+			files:   []string{"foo/bar/java-0123abcd.zip"},
+			path:    "foo/bar/java-89abcdef.zip",
+			wantErr: ErrNotFound.Error(),
+			kept:    []string{"foo/bar/java-0123abcd.zip"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(t.TempDir(), "github.com")
+			for _, file := range tt.files {
+				createFile(t, filepath.Join(s.BasePath(), file))
+			}
+			err := s.Remove(filepath.Join(s.BasePath(), tt.path))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Remove returned %v", err)
+			} else if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Remove returned %v, want an error containing %q", err, tt.wantErr)
+			}
+			for _, path := range tt.gone {
+				if exists(filepath.Join(s.BasePath(), path)) {
+					t.Errorf("%s was not removed", path)
+				}
+			}
+			for _, path := range tt.kept {
+				if !exists(filepath.Join(s.BasePath(), path)) {
+					t.Errorf("%s was removed", path)
+				}
+			}
+		})
+	}
+}
+
+// createFile creates an empty file along with its parent directories.
+func createFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
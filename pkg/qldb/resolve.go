@@ -0,0 +1,74 @@
+package qldb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrAmbiguous is returned when a request matches several databases.
+var ErrAmbiguous = errors.New("more than one database matches")
+
+// ResolveOptions selects a single database.
+type ResolveOptions struct {
+	// NWO is the repository of the database.
+	NWO string
+	// Language is the primary language of the database. It can be omitted
+	// when the repository only has databases for one language.
+	Language string
+	// Sha selects the database for this commit SHA or SHA prefix.
+	Sha string
+	// At selects the newest database created at or before this time.
+	At time.Time
+}
+
+// Resolve returns the one database matching opts. When several databases
+// for the same repository and language match, the newest one by creation
+// time is returned, unless a SHA was requested.
+func (s *Store) Resolve(opts ResolveOptions) (*Database, error) {
+	dbs, err := s.List(ListOptions{Language: opts.Language, Sha: opts.Sha})
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		db        *Database
+		createdAt time.Time
+	}
+	var candidates []candidate
+	languages := map[string]bool{}
+	for _, db := range dbs {
+		if !strings.EqualFold(db.NWO, opts.NWO) {
+			continue
+		}
+		createdAt, err := creationTime(db)
+		if err != nil {
+			return nil, err
+		}
+		if !opts.At.IsZero() && createdAt.After(opts.At) {
+			continue
+		}
+		candidates = append(candidates, candidate{db, createdAt})
+		languages[db.Language] = true
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	if len(languages) > 1 {
+		return nil, fmt.Errorf("%w: databases for several languages, set a language", ErrAmbiguous)
+	}
+	if opts.Sha != "" && len(candidates) > 1 {
+		var paths []string
+		for _, c := range candidates {
+			paths = append(paths, c.db.Path)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrAmbiguous, strings.Join(paths, ", "))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].createdAt.After(candidates[j].createdAt)
+	})
+	return candidates[0].db, nil
+}
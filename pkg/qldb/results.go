@@ -0,0 +1,113 @@
+package qldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// ResultsPath returns the directory where the analysis results of the
+// database at dbPath are stored.
+func ResultsPath(dbPath string) string {
+	return trimDBExt(dbPath) + ".results"
+}
+
+// SarifPath returns where the results of the analysis called name are
+// stored for the database at dbPath.
+func SarifPath(dbPath string, name string) string {
+	return filepath.Join(ResultsPath(dbPath), sanitizeName(name)+".sarif")
+}
+
+// AddResult stores the SARIF file at sarifPath as the results of the
+// analysis called name, run with queries, and records it in the metadata of
+// db. An analysis with the same name is replaced.
+func (s *Store) AddResult(db *Database, sarifPath string, name string, queries []string) (*utils.AnalysisMetadata, error) {
+	if name == "" {
+		name = AnalysisName(queries)
+	}
+	sarif, err := utils.ReadSarif(sarifPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SARIF file %s: %w", sarifPath, err)
+	}
+
+	dest := SarifPath(db.Path, name)
+	if src, _ := filepath.Abs(sarifPath); src != dest {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(sarifPath)
+		if err != nil {
+			return nil, err
+		}
+		_, err = writeAtomic(dest, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := utils.AnalysisMetadata{
+		Name:       sanitizeName(name),
+		Queries:    queries,
+		CliVersion: sarif.ToolVersion(),
+		Timestamp:  time.Now().UTC(),
+	}
+	err = s.updateMetadata(db, func(metadata *utils.DatabaseMetadata) {
+		var results []utils.AnalysisMetadata
+		for _, r := range metadata.Results {
+			if r.Name != result.Name {
+				results = append(results, r)
+			}
+		}
+		metadata.Results = append(results, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logf("Results stored in '%s'\n", dest)
+	return &result, nil
+}
+
+// Results returns the analyses recorded for db.
+func (s *Store) Results(db *Database) ([]utils.AnalysisMetadata, error) {
+	metadata, err := ReadMetadata(db.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return metadata.Results, nil
+}
+
+// updateMetadata applies update to the metadata file of db, creating it
+// from the codeql-database.yml file of the database if needed, and refreshes
+// the index.
+func (s *Store) updateMetadata(db *Database, update func(metadata *utils.DatabaseMetadata)) error {
+	metadata, err := ReadMetadata(db.Path)
+	if os.IsNotExist(err) {
+		metadata, err = utils.ReadDatabaseMetadata(db.Path)
+		if err == nil {
+			metadata.Provenance = db.NWO
+		}
+	}
+	if err != nil {
+		return err
+	}
+	update(metadata)
+	jsonData, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(MetadataPath(db.Path), jsonData, 0644); err != nil {
+		return err
+	}
+	db.Metadata = metadata
+	if db.NWO == "" {
+		return nil
+	}
+	return s.updateIndex(func(idx *index) { idx.add(s, db) })
+}
@@ -0,0 +1,82 @@
+package qldb
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// SchemaVersion is the version of the DatabaseRecord json schema. It is
+// increased when fields are removed or change meaning, adding fields does not
+// change it.
+const SchemaVersion = 1
+
+// Database formats.
+const (
+	FormatZip = "zip"
+	FormatDir = "dir"
+)
+
+// DatabaseRecord is the json representation of a database printed by the
+// list and info commands.
+type DatabaseRecord struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	NWO           string `json:"nwo"`
+	Owner         string `json:"owner"`
+	Repo          string `json:"repo"`
+	Language      string `json:"language"`
+	CommitSha     string `json:"commitSha"`
+	ShortSha      string `json:"shortSha"`
+	Path          string `json:"path"`
+	// Format is FormatZip or FormatDir.
+	Format string `json:"format"`
+	Size   int64  `json:"size"`
+	// CreatedAt, CliVersion and Provenance come from the database metadata
+	// and are empty when HasMetadata is false.
+	CreatedAt   *time.Time `json:"createdAt"`
+	CliVersion  string     `json:"cliVersion"`
+	Provenance  string     `json:"provenance"`
+	HasMetadata bool       `json:"hasMetadata"`
+	// CommittedDate is only known once it has been resolved using the
+	// GitHub API.
+	CommittedDate string                   `json:"committedDate,omitempty"`
+	LinesOfCode   int                      `json:"linesOfCode,omitempty"`
+	Results       []utils.AnalysisMetadata `json:"results,omitempty"`
+	Build         *utils.BuildMetadata     `json:"build,omitempty"`
+}
+
+// Record returns the json representation of db.
+func (db *Database) Record() *DatabaseRecord {
+	owner, repo, _ := strings.Cut(db.NWO, "/")
+	r := &DatabaseRecord{
+		SchemaVersion: SchemaVersion,
+		NWO:           db.NWO,
+		Owner:         owner,
+		Repo:          repo,
+		Language:      db.Language,
+		CommitSha:     db.CommitSha,
+		ShortSha:      db.ShortSha,
+		Path:          db.Path,
+		Format:        FormatZip,
+		Size:          db.Size,
+		CommittedDate: db.CommittedDate,
+	}
+	if filepath.Ext(db.Path) != ".zip" {
+		r.Format = FormatDir
+	}
+	if db.Metadata != nil {
+		r.HasMetadata = true
+		r.Provenance = db.Metadata.Provenance
+		r.LinesOfCode = db.Metadata.BaselineLinesOfCode
+		r.Results = db.Metadata.Results
+		r.Build = db.Metadata.Build
+		if c := db.Metadata.CreationMetadata; c != nil {
+			createdAt := c.CreationTime
+			r.CreatedAt = &createdAt
+			r.CliVersion = c.CliVersion
+		}
+	}
+	return r
+}
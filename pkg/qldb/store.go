@@ -0,0 +1,170 @@
+// Package qldb manages a directory tree of CodeQL databases organized by
+// host and repository (the QLDB structure):
+//
+//	<root>/<host>/<owner>/<repo>/<language>-<short sha>.zip
+//	<root>/<host>/<owner>/<repo>/<language>-<short sha>.json
+//
+// The cobra commands in the cmd package are thin wrappers around Store so the
+// same functionality can be used from other Go tools.
+package qldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// ErrNotFound is returned when no database matches a request.
+var ErrNotFound = errors.New("database not found")
+
+// Store is a QLDB directory tree.
+type Store struct {
+	// Root is the directory holding the <host> directories.
+	Root string
+	// CacheDir is the directory where zipped databases are unpacked. It
+	// defaults to a .cache directory in Root.
+	CacheDir string
+	// Cache is the eviction policy applied to the cache by Unpack.
+	Cache CacheOptions
+	// Host is the GitHub host the databases are associated to, eg:
+	// github.com or a GitHub Enterprise Server hostname.
+	Host string
+	// Log receives human readable progress messages. Nothing is written if
+	// it is nil.
+	Log io.Writer
+	// Progress receives the progress of downloads. Nothing is written if it
+	// is nil.
+	Progress io.Writer
+	// ProgressTTY draws a progress bar on Progress instead of printing
+	// periodic progress lines.
+	ProgressTTY bool
+
+	// mu serializes the log messages of concurrent operations.
+	mu sync.Mutex
+	// indexMu serializes updates to the index within the process, the index
+	// lock file serializes them across processes.
+	indexMu sync.Mutex
+}
+
+// NewStore returns a Store rooted at root for the given host.
+func NewStore(root string, host string) *Store {
+	return &Store{Root: root, Host: host}
+}
+
+// Open returns a Store for the gh default host. The root directory is
+// resolved with ResolveRoot and the cache directory and eviction policy are
+// read from the configuration file.
+func Open(root string) (*Store, error) {
+	root, err := ResolveRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	store := NewStore(root, DefaultHost())
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.Cache != "" {
+		cache, err := expandHome(config.Cache)
+		if err != nil {
+			return nil, err
+		}
+		if store.CacheDir, err = filepath.Abs(cache); err != nil {
+			return nil, err
+		}
+	}
+	if store.Cache, err = config.CacheOptions(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// BasePath returns the directory holding the <owner>/<repo> directories.
+func (s *Store) BasePath() string {
+	return filepath.Join(s.Root, s.Host)
+}
+
+// Path returns the directory where the databases for nwo are stored.
+func (s *Store) Path(nwo string) string {
+	return filepath.Join(s.BasePath(), nwo)
+}
+
+// DatabasePath returns the path of the zipped database for the given
+// repository, language and commit.
+func (s *Store) DatabasePath(nwo string, language string, commitSha string) string {
+	return filepath.Join(s.Path(nwo), Identity{NWO: nwo, Language: language, ShortSha: commitSha}.Name())
+}
+
+// MetadataPath returns the path of the JSON metadata file stored next to the
+// database at dbPath.
+func MetadataPath(dbPath string) string {
+	return trimDBExt(dbPath) + ".json"
+}
+
+// ReadMetadata reads the metadata file stored next to the database at dbPath.
+func ReadMetadata(dbPath string) (*utils.DatabaseMetadata, error) {
+	data, err := os.ReadFile(MetadataPath(dbPath))
+	if err != nil {
+		return nil, err
+	}
+	var metadata utils.DatabaseMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata file %s: %w", MetadataPath(dbPath), err)
+	}
+	return &metadata, nil
+}
+
+func (s *Store) progressOut() io.Writer {
+	if s.Progress == nil {
+		return io.Discard
+	}
+	return s.Progress
+}
+
+func (s *Store) logf(format string, a ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Log != nil {
+		fmt.Fprintf(s.Log, format, a...)
+	}
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+func trimDBExt(path string) string {
+	if filepath.Ext(path) == ".zip" {
+		return path[:len(path)-len(".zip")]
+	}
+	return path
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// diskUsage returns the size in bytes of the file or directory at path.
+func diskUsage(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,144 @@
+package qldb
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/GitHubSecurityLab/gh-qldb/utils"
+)
+
+// VerifyStatus is the outcome of verifying a database.
+type VerifyStatus string
+
+const (
+	// Verified databases match the checksum recorded in their metadata.
+	Verified VerifyStatus = "ok"
+	// Unverified databases have no checksum recorded in their metadata.
+	Unverified VerifyStatus = "no-checksum"
+	// Mismatch databases do not match the checksum or size recorded in
+	// their metadata.
+	Mismatch VerifyStatus = "mismatch"
+	// Corrupt databases cannot be read.
+	Corrupt VerifyStatus = "corrupt"
+	// Invalid databases are rejected by the CodeQL CLI.
+	Invalid VerifyStatus = "invalid"
+)
+
+// VerifyOptions controls the checks done by Verify.
+type VerifyOptions struct {
+	// ReadEntries decompresses every zip entry to check its CRC-32, rather
+	// than only reading the zip central directory.
+	ReadEntries bool
+	// Validate runs `codeql resolve database` on the database.
+	Validate bool
+}
+
+// VerifyResult is the outcome of verifying a database.
+type VerifyResult struct {
+	Database *Database
+	Status   VerifyStatus
+	Err      error
+}
+
+// Verify checks the integrity of db.
+func (s *Store) Verify(db *Database, opts VerifyOptions) *VerifyResult {
+	result := &VerifyResult{Database: db, Status: Verified}
+	fail := func(status VerifyStatus, err error) *VerifyResult {
+		result.Status, result.Err = status, err
+		return result
+	}
+
+	fi, err := os.Stat(db.Path)
+	if err != nil {
+		return fail(Corrupt, err)
+	}
+	if fi.IsDir() {
+		if _, err := utils.ReadDatabaseMetadata(db.Path); err != nil {
+			return fail(Corrupt, err)
+		}
+		if opts.Validate {
+			if err := utils.ValidateDB(db.Path); err != nil {
+				return fail(Invalid, err)
+			}
+		}
+		return result
+	}
+
+	metadata := db.Metadata
+	if m, err := ReadMetadata(db.Path); err == nil {
+		metadata = m
+	}
+	if metadata == nil || metadata.Sha256 == "" {
+		result.Status = Unverified
+	} else {
+		sum, size, err := utils.HashFile(db.Path)
+		if err != nil {
+			return fail(Corrupt, err)
+		}
+		if size != metadata.Size {
+			return fail(Mismatch, fmt.Errorf("size is %d bytes, expected %d", size, metadata.Size))
+		}
+		if sum != metadata.Sha256 {
+			return fail(Mismatch, fmt.Errorf("sha256 is %s, expected %s", sum, metadata.Sha256))
+		}
+	}
+
+	if err := checkZip(db.Path, opts.ReadEntries); err != nil {
+		return fail(Corrupt, err)
+	}
+	if opts.Validate {
+		if err := s.validateZip(db.Path); err != nil {
+			return fail(Invalid, err)
+		}
+	}
+	return result
+}
+
+// checkZip reads the central directory of the zip file at path and, if
+// readEntries is set, the contents of every entry.
+func checkZip(path string, readEntries bool) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	found := false
+	for _, f := range r.File {
+		if f.FileInfo().Name() == "codeql-database.yml" {
+			found = true
+		}
+		if !readEntries || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	if !found {
+		return errors.New("codeql-database.yml not found")
+	}
+	return nil
+}
+
+// validateZip unzips the database at path to a temporary directory and runs
+// the CodeQL CLI on it.
+func (s *Store) validateZip(path string) error {
+	tmpdir, err := os.MkdirTemp("", "qldb")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	if _, err := utils.Unzip(path, tmpdir); err != nil {
+		return err
+	}
+	return utils.ValidateDB(databaseRoot(tmpdir))
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// git runs git in dir and returns its trimmed standard output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitRemote returns the host and NWO of the repository the remote of the git
+// working tree at dir points to.
+func GitRemote(dir string, remote string) (string, string, error) {
+	remoteURL, err := git(dir, "remote", "get-url", remote)
+	if err != nil {
+		return "", "", err
+	}
+	return ParseGitURL(remoteURL)
+}
+
+// ParseGitURL returns the host and NWO of a git remote URL, such as
+// https://github.com/foo/bar.git, git@github.com:foo/bar.git or
+// ssh://git@github.com/foo/bar.
+func ParseGitURL(remoteURL string) (string, string, error) {
+	var host, path string
+	if u, err := url.Parse(remoteURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if userHost, p, ok := strings.Cut(remoteURL, ":"); ok && !strings.Contains(userHost, "/") {
+		// scp-like syntax, [user@]host:owner/repo
+		_, host, _ = strings.Cut(userHost, "@")
+		if host == "" {
+			host = userHost
+		}
+		path = p
+	}
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(path, ".git"), "/"), "/")
+	if host == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("cannot get the repository from git remote URL '%s'", remoteURL)
+	}
+	return host, parts[0] + "/" + parts[1], nil
+}
+
+// GitHead returns the commit SHA checked out in the git working tree at dir.
+func GitHead(dir string) (string, error) {
+	return git(dir, "rev-parse", "HEAD")
+}
+
+// GitDirty reports whether the git working tree at dir has uncommitted
+// changes or untracked files.
+func GitDirty(dir string) (bool, error) {
+	out, err := git(dir, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// GitClone checks out ref, a commit SHA, tag or branch, of the repository at
+// url into the empty directory dir. Only the commit at ref is fetched when the
+// server allows it. The default branch is checked out when ref is empty.
+func GitClone(url string, ref string, dir string) error {
+	if _, err := git(dir, "init", "-q"); err != nil {
+		return err
+	}
+	if _, err := git(dir, "remote", "add", "origin", url); err != nil {
+		return err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := git(dir, "fetch", "-q", "--depth=1", "origin", ref); err == nil {
+		_, err = git(dir, "checkout", "-q", "FETCH_HEAD")
+		return err
+	}
+
+	// servers can refuse to fetch a commit by SHA, fetch everything instead
+	if _, err := git(dir, "fetch", "-q", "--tags", "origin", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+		return err
+	}
+	for _, rev := range []string{ref, "origin/" + ref} {
+		if _, err := git(dir, "rev-parse", "-q", "--verify", rev+"^{commit}"); err == nil {
+			_, err = git(dir, "checkout", "-q", rev+"^{commit}")
+			return err
+		}
+	}
+	return fmt.Errorf("ref not found in %s: %s", url, ref)
+}
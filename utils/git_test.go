@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGitURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		host string
+		nwo  string
+	}{
+		{"https://github.com/foo/bar.git", "github.com", "foo/bar"},
+		{"https://github.com/foo/bar", "github.com", "foo/bar"},
+		{"https://github.com/foo/bar/", "github.com", "foo/bar"},
+		{"https://ghe.example.com:8443/foo/bar.git", "ghe.example.com", "foo/bar"},
+		{"git@github.com:foo/bar.git", "github.com", "foo/bar"},
+		{"github.com:foo/bar", "github.com", "foo/bar"},
+		{"ssh://git@github.com/foo/bar", "github.com", "foo/bar"},
+		{"ssh://git@github.com:22/foo/bar.git", "github.com", "foo/bar"},
+	}
+	for _, tt := range tests {
+		host, nwo, err := ParseGitURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseGitURL(%q) returned %v", tt.url, err)
+			continue
+		}
+		if host != tt.host || nwo != tt.nwo {
+			t.Errorf("ParseGitURL(%q) = %q, %q, want %q, %q", tt.url, host, nwo, tt.host, tt.nwo)
+		}
+	}
+
+	for _, url := range []string{
+		"",
+		"foo/bar",
+		"/tmp/foo/bar",
+		"https://github.com/foo",
+		"https://github.com/foo/bar/baz",
+		"git@github.com:foo",
+		"file:///tmp/foo/bar.git",
+	} {
+		if host, nwo, err := ParseGitURL(url); err == nil {
+			t.Errorf("ParseGitURL(%q) = %q, %q, want an error", url, host, nwo)
+		}
+	}
+}
+
+// gitTestRepo creates a bare repository with two commits on main, the first
+// one tagged v1, and a third commit on the feature branch. It returns the
+// file URL of the repository and the SHAs of the commits.
+func gitTestRepo(t *testing.T) (string, []string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, name := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(name+"_NAME", "test")
+		t.Setenv(name+"_EMAIL", "test@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	work := t.TempDir()
+	mustGit(t, work, "init", "-q")
+	mustGit(t, work, "checkout", "-q", "-b", "main")
+	var shas []string
+	commit := func(message string) {
+		if err := os.WriteFile(filepath.Join(work, "file.txt"), []byte(message), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mustGit(t, work, "add", "file.txt")
+		mustGit(t, work, "commit", "-q", "-m", message)
+		shas = append(shas, mustGit(t, work, "rev-parse", "HEAD"))
+	}
+	commit("first")
+	mustGit(t, work, "tag", "v1")
+	commit("second")
+	mustGit(t, work, "checkout", "-q", "-b", "feature")
+	commit("third")
+	mustGit(t, work, "checkout", "-q", "main")
+
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	mustGit(t, work, "clone", "-q", "--bare", work, bare)
+	return "file://" + bare, shas
+}
+
+func mustGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGitClone(t *testing.T) {
+	url, shas := gitTestRepo(t)
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", shas[1]},
+		{"main", shas[1]},
+		{"v1", shas[0]},
+		{"feature", shas[2]},
+		{shas[0], shas[0]},
+		{shas[2][:8], shas[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := GitClone(url, tt.ref, dir); err != nil {
+				t.Fatalf("GitClone(%q) returned %v", tt.ref, err)
+			}
+			head, err := GitHead(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if head != tt.want {
+				t.Errorf("GitClone(%q) checked out %s, want %s", tt.ref, head, tt.want)
+			}
+		})
+	}
+
+	if err := GitClone(url, "missing", t.TempDir()); err == nil {
+		t.Error("GitClone of a missing ref succeeded")
+	}
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DatabaseMetadata holds the contents of the codeql-database.yml file found at
+// the root of every CodeQL database. The same structure is stored as JSON in
+// the metadata file written next to each installed database.
+type DatabaseMetadata struct {
+	PrimaryLanguage      string            `yaml:"primaryLanguage" json:"primaryLanguage"`
+	CreationMetadata     *CreationMetadata `yaml:"creationMetadata" json:"creationMetadata,omitempty"`
+	BaselineLinesOfCode  int               `yaml:"baselineLinesOfCode" json:"baselineLinesOfCode"`
+	SourceLocationPrefix string            `yaml:"sourceLocationPrefix" json:"sourceLocationPrefix"`
+	UnicodeNewlines      bool              `yaml:"unicodeNewlines" json:"unicodeNewlines"`
+	ColumnKind           string            `yaml:"columnKind" json:"columnKind"`
+	Provenance           string            `yaml:"-" json:"provenance,omitempty"`
+	// Sha256 and Size describe the zip file the database is stored as. They
+	// are recorded when the database is installed.
+	Sha256 string `yaml:"-" json:"sha256,omitempty"`
+	Size   int64  `yaml:"-" json:"size,omitempty"`
+	// Results lists the analyses whose results are stored with the
+	// database.
+	Results []AnalysisMetadata `yaml:"-" json:"results,omitempty"`
+	// Build describes how the database was built, for databases created by
+	// gh-qldb.
+	Build *BuildMetadata `yaml:"-" json:"build,omitempty"`
+}
+
+// BuildMetadata describes how a database was built.
+type BuildMetadata struct {
+	// Profile is the name of the build profile that was applied, if any.
+	Profile string `json:"profile,omitempty"`
+	// Args are the arguments `codeql database create` was run with.
+	Args []string `json:"args"`
+}
+
+// AnalysisMetadata describes the results of running queries on a database.
+type AnalysisMetadata struct {
+	// Name identifies the analysis. The results are stored in <Name>.sarif.
+	Name string `json:"name"`
+	// Queries are the queries, suites or packs that were run.
+	Queries []string `json:"queries,omitempty"`
+	// CliVersion is the version of the CodeQL CLI that produced the results.
+	CliVersion string    `json:"cliVersion,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// CreationMetadata describes how and when a database was created.
+type CreationMetadata struct {
+	Sha          string    `yaml:"sha" json:"sha"`
+	CliVersion   string    `yaml:"cliVersion" json:"cliVersion"`
+	CreationTime time.Time `yaml:"creationTime" json:"creationTime"`
+}
+
+// ParseDatabaseMetadata decodes the contents of a codeql-database.yml file.
+func ParseDatabaseMetadata(yamlBytes []byte) (*DatabaseMetadata, error) {
+	var metadata DatabaseMetadata
+	if err := yaml.Unmarshal(yamlBytes, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid codeql-database.yml: %w", err)
+	}
+	return &metadata, nil
+}
+
+// Validate checks that the fields needed to place the database in the QLDB
+// structure are present.
+func (m *DatabaseMetadata) Validate() error {
+	if m.PrimaryLanguage == "" {
+		return errors.New("codeql-database.yml: missing primaryLanguage")
+	}
+	if m.CreationMetadata == nil {
+		return errors.New("codeql-database.yml: missing creationMetadata")
+	}
+	if m.CreationMetadata.Sha == "" {
+		return errors.New("codeql-database.yml: missing creationMetadata.sha")
+	}
+	if len(m.CreationMetadata.Sha) < 8 {
+		return fmt.Errorf("codeql-database.yml: invalid creationMetadata.sha '%s'", m.CreationMetadata.Sha)
+	}
+	return nil
+}
+
+// CommitSha returns the full commit SHA the database was created from, or an
+// empty string if it is unknown.
+func (m *DatabaseMetadata) CommitSha() string {
+	if m.CreationMetadata == nil {
+		return ""
+	}
+	return m.CreationMetadata.Sha
+}
+
+// ShortCommitSha returns the first 8 characters of the commit SHA.
+func (m *DatabaseMetadata) ShortCommitSha() string {
+	sha := m.CommitSha()
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// ReadDatabaseMetadata reads the codeql-database.yml file of the database at
+// path, which can be a zip file or a database directory.
+func ReadDatabaseMetadata(path string) (*DatabaseMetadata, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return readDatabaseMetadataDir(path)
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	for _, zf := range r.File {
+		if strings.HasSuffix(zf.Name, "codeql-database.yml") {
+			f, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			yamlBytes, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+			return ParseDatabaseMetadata(yamlBytes)
+		}
+	}
+	return nil, errors.New("codeql-database.yml not found")
+}
+
+// readDatabaseMetadataDir reads the codeql-database.yml file of a database
+// directory, or of the single database directory inside dir.
+func readDatabaseMetadataDir(dir string) (*DatabaseMetadata, error) {
+	yamlBytes, err := os.ReadFile(filepath.Join(dir, "codeql-database.yml"))
+	if errors.Is(err, os.ErrNotExist) {
+		entries, derr := os.ReadDir(dir)
+		if derr == nil && len(entries) == 1 && entries[0].IsDir() {
+			return readDatabaseMetadataDir(filepath.Join(dir, entries[0].Name()))
+		}
+		return nil, errors.New("codeql-database.yml not found")
+	} else if err != nil {
+		return nil, err
+	}
+	return ParseDatabaseMetadata(yamlBytes)
+}
+
+// HashFile returns the hex encoded SHA-256 and the size of the file at path.
+func HashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Progress reports the progress of a transfer. It is an io.Writer so it can
+// be fed with io.MultiWriter or io.TeeReader.
+//
+// On a terminal a progress bar is redrawn in place. Otherwise a plain line is
+// printed every Interval.
+type Progress struct {
+	// Out is where the progress is reported.
+	Out io.Writer
+	// TTY enables the in place progress bar.
+	TTY bool
+	// Name identifies the transfer in the progress lines.
+	Name string
+	// Total is the expected number of bytes, or 0 if unknown.
+	Total int64
+	// Interval is the time between two reports.
+	Interval time.Duration
+
+	done      int64
+	offset    int64
+	start     time.Time
+	lastPrint time.Time
+}
+
+// NewProgress returns a Progress for a transfer of total bytes resuming at
+// offset.
+func NewProgress(out io.Writer, tty bool, name string, offset int64, total int64) *Progress {
+	interval := 10 * time.Second
+	if tty {
+		interval = 200 * time.Millisecond
+	}
+	return &Progress{
+		Out:      out,
+		TTY:      tty,
+		Name:     name,
+		Total:    total,
+		Interval: interval,
+		done:     offset,
+		offset:   offset,
+		start:    time.Now(),
+	}
+}
+
+// Write records len(b) transferred bytes.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if time.Since(p.lastPrint) >= p.Interval {
+		p.print()
+	}
+	return len(b), nil
+}
+
+// Finish prints the final state of the transfer.
+func (p *Progress) Finish() {
+	p.print()
+	if p.TTY {
+		fmt.Fprintln(p.Out)
+	}
+}
+
+func (p *Progress) print() {
+	p.lastPrint = time.Now()
+	elapsed := time.Since(p.start).Seconds()
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.done-p.offset) / elapsed
+	}
+	status := fmt.Sprintf("%s / %s", FormatSize(p.done), FormatSize(p.Total))
+	eta := "--"
+	if p.Total > 0 && rate > 0 {
+		eta = (time.Duration(float64(p.Total-p.done)/rate) * time.Second).Round(time.Second).String()
+	}
+	if p.Total <= 0 {
+		status = FormatSize(p.done)
+	}
+	line := fmt.Sprintf("%s %s %s/s ETA %s", p.Name, status, FormatSize(int64(rate)), eta)
+	if !p.TTY {
+		fmt.Fprintln(p.Out, line)
+		return
+	}
+	const width = 30
+	bar := strings.Repeat(" ", width)
+	if p.Total > 0 {
+		filled := int(float64(width) * float64(p.done) / float64(p.Total))
+		if filled > width {
+			filled = width
+		}
+		bar = strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	}
+	fmt.Fprintf(p.Out, "\r[%s] %s\033[K", bar, line)
+}
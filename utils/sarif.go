@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Sarif is the subset of a SARIF log used to summarize CodeQL results.
+type Sarif struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name            string `json:"name"`
+				SemanticVersion string `json:"semanticVersion"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// ReadSarif parses the SARIF file at path.
+func ReadSarif(path string) (*Sarif, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sarif Sarif
+	if err := json.Unmarshal(data, &sarif); err != nil {
+		return nil, err
+	}
+	return &sarif, nil
+}
+
+// ToolVersion returns the version of the tool that produced the first run.
+func (s *Sarif) ToolVersion() string {
+	if len(s.Runs) == 0 {
+		return ""
+	}
+	return s.Runs[0].Tool.Driver.SemanticVersion
+}
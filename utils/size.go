@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB"}
+
+// FormatSize formats a size in bytes using the largest fitting unit. Units
+// are powers of 1024.
+func FormatSize(size int64) string {
+	value := float64(size)
+	i := 0
+	for value >= 1024 && i < len(sizeUnits)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", size, sizeUnits[0])
+	}
+	return fmt.Sprintf("%.1f%s", value, sizeUnits[i])
+}
+
+// ParseSize parses a size such as 500MB or 1.5GB. Units are powers of 1024.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	for i := len(sizeUnits) - 1; i >= 0; i-- {
+		unit := sizeUnits[i]
+		if !strings.HasSuffix(value, unit) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, unit)), 64)
+		if err != nil || n < 0 {
+			break
+		}
+		return int64(n * float64(int64(1)<<(10*i))), nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid size: " + s)
+	}
+	return n, nil
+}
@@ -2,37 +2,20 @@ package utils
 
 import (
 	"archive/zip"
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
-	"gopkg.in/yaml.v3"
-
 	"github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/api"
 	graphql "github.com/shurcooL/githubv4"
 )
 
-const (
-	ROOT = "codeql-dbs"
-	VCS  = "github.com"
-)
-
-func GetBasePath() string {
-	home := os.Getenv("HOME")
-	return filepath.Join(home, ROOT, VCS)
-}
-
-func GetPath(nwo string) string {
-	return filepath.Join(GetBasePath(), nwo)
-}
-
 func ValidateDB(dbPath string) error {
 	cmd := exec.Command("codeql", "resolve", "database", dbPath)
 	cmd.Env = os.Environ()
@@ -48,32 +31,18 @@ func ValidateDB(dbPath string) error {
 	return nil
 }
 
-func ExtractDBInfo(body []byte) (map[string]interface{}, error) {
-	zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+// ExtractDBInfo reads and validates the codeql-database.yml file of the
+// database at path, which can be a zip file or a database directory. Zip files
+// are read in place so the database is never loaded in memory.
+func ExtractDBInfo(path string) (*DatabaseMetadata, error) {
+	metadata, err := ReadDatabaseMetadata(path)
 	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Print("Extracting database information ... ")
-	for _, zf := range zipReader.File {
-		if strings.HasSuffix(zf.Name, "codeql-database.yml") {
-			f, err := zf.Open()
-			if err != nil {
-				log.Fatal(err)
-			}
-			defer f.Close()
-			yamlBytes, err := io.ReadAll(f)
-			if err != nil {
-				log.Fatal(err)
-			}
-			var dbData map[string]interface{}
-			err = yaml.Unmarshal(yamlBytes, &dbData)
-			if err != nil {
-				log.Fatal(err)
-			}
-			return dbData, nil
-		}
+		return nil, err
 	}
-	return nil, errors.New("codeql-database.yml not found")
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+	return metadata, nil
 }
 
 // Unzip will decompress a zip archive, moving all files and folders
@@ -207,14 +176,12 @@ func ZipDirectory(zipFileName string, directoryToZip string) error {
 		return err
 	}
 
-	fmt.Printf("Successfully created zip file %s\n", zipFileName)
-
 	return nil
 }
 
-func GetCommitInfo(nwo string, commitSha string) (string, string, error) {
+func GetCommitInfo(host string, nwo string, commitSha string) (string, string, error) {
 
-	graphqlClient, err := gh.GQLClient(nil)
+	graphqlClient, err := gh.GQLClient(&api.ClientOptions{Host: host})
 	if err != nil {
 		return "", "", err
 	}
@@ -241,15 +208,21 @@ func GetCommitInfo(nwo string, commitSha string) (string, string, error) {
 	return string(query.Repository.Object.Commit.AbbreviatedOid), query.Repository.Object.Commit.CommittedDate.Format("2006-01-02T15:04:05"), nil
 }
 
-func GetCommitInfo2(nwo string, commitSha string) (string, string, error) {
-	restClient, err := gh.RESTClient(nil)
-	response := make(map[string]interface{})
+func GetCommitInfo2(host string, nwo string, commitSha string) (string, string, error) {
+	restClient, err := gh.RESTClient(&api.ClientOptions{Host: host})
+	if err != nil {
+		return "", "", err
+	}
+	var response struct {
+		Commit struct {
+			Committer struct {
+				Date string `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
 	err = restClient.Get(fmt.Sprintf("repos/%s/commits/%s", nwo, commitSha), &response)
 	if err != nil {
-		log.Fatal(err)
+		return "", "", err
 	}
-	commitMap := response["commit"].(map[string]interface{})
-	committerMap := commitMap["committer"].(map[string]interface{})
-	dateString := committerMap["date"].(string)
-	return commitSha, dateString, nil
+	return commitSha, response.Commit.Committer.Date, nil
 }